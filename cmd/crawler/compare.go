@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"webcrawler/internal/crawler"
+)
+
+// runCompare prints the differences the summarizer finds between two pages.
+func runCompare(ctx context.Context, c *crawler.Crawler, urlA, urlB string) error {
+	comparison, err := c.Compare(ctx, urlA, urlB)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Page A:  %s (%s, %d bytes)\n", comparison.A.URL, comparison.A.Title, len(comparison.A.Content))
+	fmt.Printf("Page B:  %s (%s, %d bytes)\n", comparison.B.URL, comparison.B.Title, len(comparison.B.Content))
+	fmt.Printf("\n--- Differences ---\n%s\n", comparison.Differences)
+	return nil
+}
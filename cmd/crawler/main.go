@@ -3,58 +3,378 @@ package main
 import (
 	"context"
 	"flag"
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"webcrawler/config"
 	"webcrawler/internal/crawler"
+	"webcrawler/internal/parser"
+	"webcrawler/internal/sink"
+	"webcrawler/internal/storage"
 	"webcrawler/internal/summarizer"
 )
 
 func main() {
-	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile)
-	log.SetOutput(os.Stdout)
+	if err := run(); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
 
-	seedURL := flag.String("url", "", "The seed URL to start crawling from")
-	configPath := flag.String("config", "", "Path to configuration file")
-	verbose := flag.Bool("verbose", false, "Enable verbose logging")
+// run does the work of main. Errors are returned rather than exiting on
+// the spot, so that deferred cleanup, Crawler.Close above all, still runs.
+func run() error {
+	var seedURLs seedList
+	flag.Var(&seedURLs, "url", "The seed URL to start crawling from; repeat it or separate URLs with commas to crawl several")
+	seedsFile := flag.String("seeds-file", "", "Also crawl the seed URLs in this file, one per line (# starts a comment)")
+	configPath := flag.String("config", "", "Path to a JSON or YAML (.yaml, .yml) configuration file")
+	verbose := flag.Bool("verbose", false, "Enable verbose (debug level) logging")
+	previewURL := flag.String("preview", "", "Show the extracted content and summary for a single URL, then exit")
+	compare := flag.Bool("compare", false, "Compare two pages given as arguments (-compare url1 url2) and print their differences")
+	resummarize := flag.Bool("resummarize-changed", false, "Regenerate stored summaries made with a different model or prompt, without crawling")
+	format := flag.String("format", "text", "How to print results: \"text\", \"table\" or \"jsonl\" (one JSON object per line)")
+	output := flag.String("output", "", "Write -format jsonl or table results to this file instead of stdout")
+	dbPath := flag.String("db", "", "Save results to this SQLite database and skip URLs it already has")
+	sitemap := flag.String("sitemap", "", "Also seed the crawl with the URLs in this sitemap.xml (index or .xml.gz); -url defaults to its site root")
+	resume := flag.Bool("resume", false, "Continue the interrupted crawl saved in -db from its pending URLs")
+	discoverOnly := flag.Bool("discover-only", false, "Only discover URLs up to maxDepth, without summarizing pages (sets skipSummary)")
+	graphPath := flag.String("graph", "", "Write the links between crawled pages to this file, as Graphviz DOT (.dot, .gv) or a JSON adjacency list")
+	userAgent := flag.String("user-agent", "", "Send this User-Agent instead of the configured one (default a desktop Chrome)")
+	bustCache := flag.String("bust-summary-cache", "", "Delete the summaries cached in summaryCacheDir for this model, then exit unless URLs are given")
 	flag.Parse()
 
-	if *seedURL == "" {
-		log.Fatal("Please provide a seed URL using the -url flag")
+	// -output used to choose between log and table output.
+	switch *output {
+	case "log":
+		*format, *output = "text", ""
+	case "table":
+		*format, *output = "table", ""
 	}
-
-	log.Printf("Starting crawler with URL: %s\n", *seedURL)
-	log.Printf("Using config file: %s\n", *configPath)
+	if *format != "text" && *format != "table" && *format != "jsonl" {
+		return fmt.Errorf("unknown -format %q (want text, table or jsonl)", *format)
+	}
+	if *format == "text" && *output != "" {
+		return fmt.Errorf("-output needs -format jsonl or table")
+	}
+	logOut := io.Writer(os.Stdout)
+	if *format != "text" && *output == "" {
+		// Keep stdout for the results; logs go to stderr.
+		logOut = os.Stderr
+	}
+	logLevel := slog.LevelInfo
 	if *verbose {
-		log.Println("Verbose logging enabled")
+		logLevel = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(logOut, &slog.HandlerOptions{Level: logLevel}))
+	slog.SetDefault(logger)
+
+	if *compare && flag.NArg() != 2 {
+		return fmt.Errorf("please provide exactly two URLs to -compare")
+	}
+	if *seedsFile != "" {
+		seeds, err := readSeedsFile(*seedsFile)
+		if err != nil {
+			return err
+		}
+		seedURLs = append(seedURLs, seeds...)
+	}
+	if len(seedURLs) == 0 && *sitemap != "" {
+		sitemapURL, err := url.Parse(*sitemap)
+		if err != nil || !sitemapURL.IsAbs() {
+			return fmt.Errorf("invalid -sitemap URL %q", *sitemap)
+		}
+		seedURLs = append(seedURLs, (&url.URL{Scheme: sitemapURL.Scheme, Host: sitemapURL.Host, Path: "/"}).String())
+	}
+	if len(seedURLs) == 0 && *previewURL == "" && !*resummarize && !*compare && *bustCache == "" {
+		return fmt.Errorf("please provide a seed URL using the -url or -seeds-file flag")
+	}
+
+	if len(seedURLs) > 0 {
+		logger.Info("Starting crawler", "urls", []string(seedURLs))
 	}
+	logger.Info("Using config file", "path", *configPath)
+	logger.Debug("Verbose logging enabled")
 
 	cfg, err := config.LoadConfig(*configPath)
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %v", err)
+	}
+	if *userAgent != "" {
+		cfg.UserAgent = *userAgent
+	}
+	if *discoverOnly {
+		cfg.SkipSummary = true
+	}
+
+	if *bustCache != "" {
+		if cfg.SummaryCacheDir == "" {
+			return fmt.Errorf("-bust-summary-cache needs summaryCacheDir in the configuration")
+		}
+		if err := summarizer.NewSummaryCache(cfg.SummaryCacheDir).Bust(*bustCache); err != nil {
+			return err
+		}
+		logger.Info("Cleared summary cache", "model", *bustCache)
+		if len(seedURLs) == 0 && *previewURL == "" && !*resummarize && !*compare {
+			return nil
+		}
 	}
 
 	crawlerConfig := &crawler.Config{
-		MaxDepth:   cfg.MaxDepth,
-		RateLimit:  time.Second / time.Duration(cfg.RateLimit),
-		MaxWorkers: cfg.MaxWorkers,
+		MaxDepth:        cfg.MaxDepth,
+		RateLimit:       time.Duration(float64(time.Second) / cfg.RateLimit),
+		MaxWorkers:      cfg.MaxWorkers,
+		MaxPages:        cfg.MaxPages,
+		MaxRedirects:    cfg.MaxRedirects,
+		MaxContentBytes: cfg.MaxContentBytes,
+		MaxRetries:      cfg.MaxRetries,
+		MaxFetchRetries: cfg.MaxFetchRetries,
+
+		SummarizeConcurrency: cfg.SummarizeConcurrency,
+		SelfCritique:         cfg.SelfCritique,
+
+		GlobalRateLimit:  cfg.GlobalRateLimit,
+		RateLimitJitter:  cfg.RateLimitJitter,
+		IgnoreRobots:     cfg.IgnoreRobots,
+		IgnoreCrawlDelay: cfg.IgnoreCrawlDelay,
+		IgnoreRobotsMeta: cfg.IgnoreRobotsMeta,
+		FrontierStrategy: cfg.FrontierStrategy,
+		PriorityScore:    keywordScore(cfg.PriorityKeywords),
+		FocusQuery:       cfg.FocusQuery,
+		FocusThreshold:   cfg.FocusThreshold,
+
+		AllowedHosts:       cfg.AllowedHosts,
+		CrossSubdomainHops: cfg.CrossSubdomainHops,
+
+		LinkSource:          cfg.LinkSource,
+		TrailingSlash:       cfg.TrailingSlash,
+		KeepSelfLinks:       cfg.KeepSelfLinks,
+		HashRouting:         cfg.HashRouting,
+		SelfLinkParams:      cfg.SelfLinkParams,
+		StripTrackingParams: cfg.StripTrackingParams,
+		IncludePatterns:     cfg.IncludePatterns,
+		ExcludePatterns:     cfg.ExcludePatterns,
+
+		RenderMode:          cfg.RenderMode,
+		Browser:             cfg.Browser,
+		BrowserWSEndpoint:   cfg.BrowserWSEndpoint,
+		ProxyURL:            cfg.ProxyURL,
+		UserAgent:           cfg.UserAgent,
+		Headers:             cfg.Headers,
+		BrowserContexts:     cfg.BrowserContexts,
+		WaitForSelector:     cfg.WaitForSelector,
+		BlockResources:      cfg.BlockResources,
+		BlockResourceTypes:  cfg.BlockResourceTypes,
+		Screenshot:          cfg.Screenshot,
+		ScreenshotDir:       cfg.ScreenshotDir,
+		ContextMaxUses:      cfg.ContextMaxUses,
+		Device:              cfg.Device,
+		Viewport:            parser.Viewport{Width: cfg.Viewport.Width, Height: cfg.Viewport.Height},
+		DeviceScaleFactor:   cfg.DeviceScaleFactor,
+		PreserveStructure:   cfg.PreserveStructure,
+		MinContentLength:    cfg.MinContentLength,
+		QuietBodyFallback:   cfg.QuietBodyFallback,
+		IncludeComments:     cfg.IncludeComments,
+		SummarizeComments:   cfg.SummarizeComments,
+		PrimaryLanguageOnly: cfg.PrimaryLanguageOnly,
+		LanguageGranularity: cfg.LanguageGranularity,
+		AllowedLangs:        cfg.AllowedLangs,
+		DetectLang:          cfg.DetectLang,
+		MergePatterns:       cfg.MergePatterns,
+		RedactPatterns:      cfg.RedactPatterns,
+		RedactPlaceholder:   cfg.RedactPlaceholder,
+		Charset:             cfg.Charset,
+		PreferAMP:           cfg.PreferAMP,
+		UseNoscriptFallback: cfg.UseNoscriptFallback,
+		DepthPrompts:        cfg.DepthPrompts,
+		CustomExtractScript: cfg.CustomExtractScript,
+		ContentSelectors:    cfg.ContentSelectors,
+		RemoveSelectors:     cfg.RemoveSelectors,
+		ExtractMode:         cfg.ExtractMode,
+
+		SummarizerOptional: cfg.SummarizerOptional,
+		LinkCheckOnly:      cfg.LinkCheckOnly,
+		SkipSummary:        cfg.SkipSummary,
+
+		FailuresFile:    cfg.FailuresFile,
+		BrokenLinksFile: cfg.BrokenLinksFile,
+		ContentHashFile: cfg.ContentHashFile,
+		SummaryCacheDir: cfg.SummaryCacheDir,
+
+		AcceptStatusCodes:  cfg.AcceptStatusCodes,
+		AcceptStatusRanges: cfg.AcceptStatusRanges,
+	}
+
+	if len(cfg.HostProfiles) > 0 {
+		crawlerConfig.HostProfiles = make(map[string]crawler.HostProfile, len(cfg.HostProfiles))
+		for host, profile := range cfg.HostProfiles {
+			hostProfile := crawler.HostProfile{
+				Headers:       profile.Headers,
+				Cookies:       profile.Cookies,
+				UserAgent:     profile.UserAgent,
+				TrailingSlash: profile.TrailingSlash,
+
+				ContentSelectors: profile.ContentSelectors,
+				RemoveSelectors:  profile.RemoveSelectors,
+			}
+			if profile.RateLimit > 0 {
+				hostProfile.RateLimit = time.Duration(float64(time.Second) / profile.RateLimit)
+			}
+			crawlerConfig.HostProfiles[strings.ToLower(host)] = hostProfile
+		}
+	}
+
+	logger.Info("Crawler config", "max_depth", crawlerConfig.MaxDepth,
+		"rate_limit", crawlerConfig.RateLimit, "max_workers", crawlerConfig.MaxWorkers)
+
+	pageSummarizer, err := cfg.CreateSummarizer(logger)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %v", err)
+	}
+
+	if cfg.SummarizeBatchWindow != "" {
+		window, err := time.ParseDuration(cfg.SummarizeBatchWindow)
+		if err != nil {
+			return fmt.Errorf("invalid summarize batch window %q: %v", cfg.SummarizeBatchWindow, err)
+		}
+		crawlerConfig.SummarizeBatchWindow = window
+	}
+
+	if cfg.StartupTimeout != "" {
+		timeout, err := time.ParseDuration(cfg.StartupTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid startup timeout %q: %v", cfg.StartupTimeout, err)
+		}
+		crawlerConfig.StartupTimeout = timeout
+	}
+
+	if cfg.ContextMaxAge != "" {
+		maxAge, err := time.ParseDuration(cfg.ContextMaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid context max age %q: %v", cfg.ContextMaxAge, err)
+		}
+		crawlerConfig.ContextMaxAge = maxAge
+	}
+
+	if cfg.RetryBackoff != "" {
+		backoff, err := time.ParseDuration(cfg.RetryBackoff)
+		if err != nil {
+			return fmt.Errorf("invalid retry backoff %q: %v", cfg.RetryBackoff, err)
+		}
+		crawlerConfig.RetryBackoff = backoff
+	}
+	if cfg.ShutdownTimeout != "" {
+		timeout, err := time.ParseDuration(cfg.ShutdownTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid shutdown timeout %q: %v", cfg.ShutdownTimeout, err)
+		}
+		crawlerConfig.ShutdownTimeout = timeout
+	}
+	if cfg.WaitForTimeout != "" {
+		wait, err := time.ParseDuration(cfg.WaitForTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid wait for timeout %q: %v", cfg.WaitForTimeout, err)
+		}
+		crawlerConfig.WaitForTimeout = wait
+	}
+	var recrawlInterval time.Duration
+	if cfg.RecrawlInterval != "" {
+		recrawlInterval, err = time.ParseDuration(cfg.RecrawlInterval)
+		if err != nil {
+			return fmt.Errorf("invalid recrawl interval %q: %v", cfg.RecrawlInterval, err)
+		}
+	}
+
+	cassetteMode, err := crawler.ParseCassetteMode(cfg.CassetteMode)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %v", err)
 	}
+	crawlerConfig.Cassette = cfg.Cassette
+	crawlerConfig.CassetteMode = cassetteMode
+	crawlerConfig.CassetteBrowser = cfg.CassetteBrowser
 
-	log.Printf("Crawler config: MaxDepth=%d, RateLimit=%v, MaxWorkers=%d\n",
-		crawlerConfig.MaxDepth, crawlerConfig.RateLimit, crawlerConfig.MaxWorkers)
+	outputMode, err := crawler.ParseOutputMode(cfg.OutputMode)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %v", err)
+	}
+	crawlerConfig.OutputMode = outputMode
 
-	ollamaSummarizer := summarizer.NewOllamaSummarizer("http://localhost:11434", "mistral")
+	opts := []crawler.Option{crawler.WithLogger(logger)}
+	for _, spec := range cfg.ResultFilters {
+		filter, err := crawler.ParseResultFilter(spec)
+		if err != nil {
+			return fmt.Errorf("invalid result filter: %v", err)
+		}
+		opts = append(opts, crawler.WithResultFilter(filter))
+	}
+	if cfg.JSONLDOutput != "" {
+		jsonld, err := sink.NewJSONLDSink(cfg.JSONLDOutput, outputMode)
+		if err != nil {
+			return fmt.Errorf("failed to open JSON-LD output: %v", err)
+		}
+		opts = append(opts, crawler.WithSink(jsonld))
+	}
+	if *graphPath != "" {
+		opts = append(opts, crawler.WithSink(sink.NewGraphSink(*graphPath)))
+	}
+	if cfg.ElasticURL != "" {
+		opts = append(opts, crawler.WithSink(sink.NewElasticSink(cfg.ElasticURL, cfg.ElasticIndex, outputMode)))
+	}
+	crawlerConfig.SitemapURL = *sitemap
+	if *resume {
+		if *dbPath == "" {
+			return fmt.Errorf("-resume needs -db")
+		}
+		if outputMode == crawler.OutputOverwrite {
+			return fmt.Errorf("-resume can't be used with outputMode overwrite, which clears the database")
+		}
+		crawlerConfig.Resume = true
+	}
+	if *dbPath != "" {
+		store, err := storage.OpenSQLite(*dbPath, outputMode)
+		if err != nil {
+			return fmt.Errorf("failed to open database: %v", err)
+		}
+		defer func() {
+			if err := store.Close(); err != nil {
+				logger.Error("Failed to close database", "error", err)
+			}
+		}()
+		opts = append(opts, crawler.WithStore(store))
+	}
+	if *format == "jsonl" {
+		// Results are written by the sink as they are recorded, so nothing
+		// is held in memory for the whole crawl.
+		jsonl := sink.NewJSONLSink(os.Stdout)
+		if *output != "" {
+			jsonl, err = sink.OpenJSONLSink(*output, outputMode)
+			if err != nil {
+				return fmt.Errorf("failed to open JSONL output: %v", err)
+			}
+		}
+		opts = append(opts, crawler.WithSink(jsonl))
+	}
 
-	crawler, err := crawler.New(crawlerConfig, ollamaSummarizer)
+	c, err := crawler.New(crawlerConfig, pageSummarizer, opts...)
 	if err != nil {
-		log.Fatalf("Failed to create crawler: %v", err)
+		return fmt.Errorf("failed to create crawler: %v", err)
 	}
+	defer func() {
+		if err := c.Close(); err != nil {
+			logger.Error("Failed to shut down crawler", "error", err)
+		}
+	}()
 
-	log.Println("\nStarting crawl process...")
+	logger.Info("Starting crawl process")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -64,31 +384,162 @@ func main() {
 
 	go func() {
 		<-sigChan
-		log.Println("\nReceived shutdown signal. Cancelling operations...")
+		logger.Info("Received shutdown signal, finishing pages in progress (interrupt again to quit now)")
 		cancel()
+		<-sigChan
+		logger.Info("Received second shutdown signal, closing the crawler")
+		closed := make(chan struct{})
+		go func() {
+			if err := c.Close(); err != nil {
+				logger.Error("Failed to shut down crawler", "error", err)
+			}
+			close(closed)
+		}()
+		select {
+		case <-closed:
+		case <-time.After(forceCloseTimeout):
+			logger.Error("Timed out closing the crawler", "timeout", forceCloseTimeout)
+		}
+		logger.Error("Quitting before pages in progress finished")
+		os.Exit(1)
 	}()
 
-	results, err := crawler.Crawl(ctx, *seedURL)
-	if err != nil {
-		log.Fatalf("Failed to start crawler: %v", err)
+	if *compare {
+		if err := runCompare(ctx, c, flag.Arg(0), flag.Arg(1)); err != nil {
+			logger.Error("Comparison failed", "error", err)
+		}
+		return nil
 	}
 
-	log.Println("Crawler started successfully, waiting for results...")
+	if *previewURL != "" {
+		if err := runPreview(ctx, c, *previewURL, *verbose); err != nil {
+			logger.Error("Preview failed", "url", *previewURL, "error", err)
+		}
+		return nil
+	}
 
-	for result := range results {
+	var table *tableWriter
+	if *format == "table" {
+		out := os.Stdout
+		if *output != "" {
+			out, err = os.Create(*output)
+			if err != nil {
+				return fmt.Errorf("failed to open table output: %v", err)
+			}
+			defer out.Close()
+		}
+		table = newTableWriter(out)
+		table.header()
+	}
+
+	handleResult := func(result crawler.Result) {
+		if table != nil {
+			table.write(result)
+			return
+		}
+		if *format == "jsonl" {
+			return
+		}
 		if result.Error != nil {
-			log.Printf("Error crawling %s: %v\n", result.URL, result.Error)
-			continue
+			fmt.Printf("\nError crawling %s: %v\n", result.URL, result.Error)
+			return
 		}
 
-		log.Printf("\nProcessed URL: %s (depth: %d)\n", result.URL, result.Depth)
+		fmt.Printf("\nProcessed URL: %s (depth: %d)\n", result.URL, result.Depth)
 		if result.Summary != "" {
-			log.Printf("Summary: %s\n", result.Summary)
+			fmt.Printf("Summary: %s\n", result.Summary)
+		}
+		if cfg.SkipSummary {
+			fmt.Printf("Links: %d\n", len(result.Links))
 		}
 		if *verbose {
-			log.Printf("Content length: %d bytes\n", len(result.Content))
+			fmt.Printf("Content length: %d bytes\n", len(result.Content))
+		}
+	}
+
+	if *resummarize {
+		// Errors are logged rather than fatal so that Close still saves the
+		// summaries regenerated so far.
+		count, err := c.ResummarizeChanged(ctx, handleResult)
+		if err != nil {
+			logger.Error("Resummarizing stopped", "error", err)
 		}
+		logger.Info("Regenerated summaries", "count", count)
+		return nil
 	}
 
-	log.Println("\nCrawling completed!")
+	if recrawlInterval > 0 {
+		logger.Info("Re-crawling until interrupted", "interval", recrawlInterval)
+		scheduler := crawler.NewScheduler(c, recrawlInterval, seedURLs...)
+		if err := scheduler.Run(ctx, handleResult); err != nil && err != context.Canceled {
+			return fmt.Errorf("scheduled crawl failed: %v", err)
+		}
+		printStats(logOut, c.Stats(), cfg.BrokenLinksFile)
+		status := scheduler.Status()
+		logger.Info("Scheduler stopped", "runs", status.Runs, "last_run_pages", status.LastRunPages,
+			"last_run_errors", status.LastRunErrors, "last_run_end", status.LastRunEnd.Format(time.RFC3339))
+		return nil
+	}
+
+	results, err := c.Crawl(ctx, seedURLs...)
+	if err != nil {
+		return fmt.Errorf("failed to start crawler: %v", err)
+	}
+
+	logger.Info("Crawler started, waiting for results")
+
+	for result := range results {
+		handleResult(result)
+	}
+
+	printStats(logOut, c.Stats(), cfg.BrokenLinksFile)
+	logger.Info("Crawling completed")
+	return nil
+}
+
+// printStats writes the summary block shown when a crawl ends to out,
+// which is where the logs go.
+func printStats(out io.Writer, stats crawler.Stats, brokenLinksFile string) {
+	fmt.Fprintln(out, "\nCrawl statistics:")
+	fmt.Fprintf(out, "Pages fetched: %d of %d discovered (%d succeeded, %d failed)\n", stats.Fetched, stats.Discovered, stats.Succeeded, stats.Failed)
+	for _, category := range stats.ErrorCategories() {
+		fmt.Fprintf(out, "Errors (%s): %d\n", category, stats.Errors[category])
+	}
+	fmt.Fprintf(out, "Downloaded: %.1f KiB\n", float64(stats.BytesDownloaded)/1024)
+	fmt.Fprintf(out, "Summaries generated: %d (%d failed)\n", stats.Summaries, stats.SummaryErrors)
+	fmt.Fprintf(out, "Elapsed: %v (%.2f pages/s)\n", stats.Elapsed.Round(time.Millisecond), stats.PagesPerSecond)
+	fmt.Fprintf(out, "Links found: %d internal, %d external\n", stats.InternalLinks, stats.ExternalLinks)
+	if stats.Filtered > 0 {
+		fmt.Fprintf(out, "Results dropped by filters: %d\n", stats.Filtered)
+	}
+	for _, reason := range stats.SkipReasons() {
+		fmt.Fprintf(out, "Skipped (%s): %d\n", reason, stats.Skipped[reason])
+	}
+	if stats.BodyFallbacks > 0 {
+		fmt.Fprintf(out, "Pages extracted from <body> (no content selector matched): %d\n", stats.BodyFallbacks)
+	}
+	if stats.Redactions > 0 {
+		fmt.Fprintf(out, "Redactions before summarizing: %d\n", stats.Redactions)
+	}
+	if brokenLinksFile != "" {
+		fmt.Fprintf(out, "Broken links: %d (see %s)\n", stats.BrokenLinks, brokenLinksFile)
+	}
 }
+
+// keywordScore ranks URLs by how many of the keywords they contain.
+func keywordScore(keywords []string) crawler.ScoreFunc {
+	return func(item crawler.FrontierItem) float64 {
+		target := strings.ToLower(item.URL)
+		score := 0.0
+		for _, keyword := range keywords {
+			if keyword != "" && strings.Contains(target, strings.ToLower(keyword)) {
+				score++
+			}
+		}
+		return score
+	}
+}
+
+// forceCloseTimeout bounds how long a second interrupt waits for the
+// crawler to close its browser and flush its sinks before exiting.
+const forceCloseTimeout = 5 * time.Second
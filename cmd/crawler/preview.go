@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"webcrawler/internal/crawler"
+)
+
+// previewTextLimit is how much extracted text -preview prints unless
+// -verbose is set.
+const previewTextLimit = 2000
+
+// runPreview prints what the crawler extracts and generates for a single
+// URL.
+func runPreview(ctx context.Context, c *crawler.Crawler, url string, verbose bool) error {
+	result, err := c.Preview(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	text := result.Content
+	if !verbose && len(text) > previewTextLimit {
+		// Cut at the start of a rune so the output stays valid UTF-8.
+		cut := previewTextLimit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + fmt.Sprintf("\n... (%d more bytes, use -verbose to show all)", len(result.Content)-cut)
+	}
+
+	fmt.Printf("URL:         %s\n", result.URL)
+	if result.FinalURL != "" && result.FinalURL != result.URL {
+		fmt.Printf("Final URL:   %s (%d redirects)\n", result.FinalURL, result.RedirectCount)
+	}
+	if result.AMPURL != "" {
+		fmt.Printf("AMP URL:     %s\n", result.AMPURL)
+	}
+	fmt.Printf("Status:      %d\n", result.StatusCode)
+	fmt.Printf("Title:       %s\n", result.Title)
+	fmt.Printf("Confidence:  %.2f (selector %q)\n", result.Confidence, result.MatchedSelector)
+	fmt.Printf("Links:       %d (%d internal, %d external)\n", len(result.Links), result.InternalLinks, result.ExternalLinks)
+	fmt.Printf("Content:     %d bytes\n", len(result.Content))
+	fmt.Printf("\n--- Extracted text ---\n%s\n", text)
+	fmt.Printf("\n--- Summary (%s) ---\n%s\n", result.SummaryFormat, result.Summary)
+	if result.SummaryScore > 0 {
+		fmt.Printf("\nSelf-critique: %d/5 (unsummarizable: %v) %s\n", result.SummaryScore, result.SourceUnsummarizable, result.CritiqueReason)
+	}
+	return nil
+}
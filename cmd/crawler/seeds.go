@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// seedList collects -url flags; each may hold several comma-separated
+// URLs.
+type seedList []string
+
+func (s *seedList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *seedList) Set(value string) error {
+	for _, seed := range strings.Split(value, ",") {
+		if seed = strings.TrimSpace(seed); seed != "" {
+			*s = append(*s, seed)
+		}
+	}
+	return nil
+}
+
+// readSeedsFile returns the URLs in path, one per line. Blank lines and
+// anything after a '#' are ignored, so a failures file can be read back.
+func readSeedsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open seeds file: %v", err)
+	}
+	defer file.Close()
+
+	var seeds []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		seeds = append(seeds, fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read seeds file: %v", err)
+	}
+	return seeds, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"webcrawler/internal/crawler"
+)
+
+const (
+	defaultTableWidth = 120
+	tableURLWidth     = 60
+	tableMinSummary   = 20
+)
+
+// tableWriter prints one fixed-width row per result: depth, status, URL and
+// the first line of the summary. Columns are separated by spaces and only
+// the last one may contain spaces, so rows split cleanly with awk.
+type tableWriter struct {
+	out      io.Writer
+	width    int
+	urlWidth int
+}
+
+func newTableWriter(out io.Writer) *tableWriter {
+	width := defaultTableWidth
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		width = columns
+	}
+	urlWidth := tableURLWidth
+	// depth(5) + status(6) + three separators leave the rest for URL and summary.
+	if rest := width - 5 - 6 - 3 - tableMinSummary; rest < urlWidth {
+		urlWidth = max(rest, 20)
+	}
+	return &tableWriter{out: out, width: width, urlWidth: urlWidth}
+}
+
+func (t *tableWriter) header() {
+	t.row("DEPTH", "STATUS", "URL", "SUMMARY")
+}
+
+func (t *tableWriter) write(result crawler.Result) {
+	status := strconv.Itoa(result.StatusCode)
+	summary := firstLine(result.Summary)
+	if result.Error != nil {
+		status = "ERR"
+		summary = result.Error.Error()
+	}
+	if summary == "" {
+		summary = "-"
+	}
+	t.row(strconv.Itoa(result.Depth), status, result.URL, summary)
+}
+
+func (t *tableWriter) row(depth, status, url, summary string) {
+	summaryWidth := max(t.width-5-6-t.urlWidth-3, tableMinSummary)
+	fmt.Fprintf(t.out, "%-5s %-6s %-*s %s\n",
+		truncate(depth, 5), truncate(status, 6),
+		t.urlWidth, truncate(url, t.urlWidth), truncate(summary, summaryWidth))
+}
+
+// firstLine returns the first non-blank line of s, with list markers and
+// Markdown emphasis stripped.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(strings.Trim(strings.TrimSpace(line), "#*-"))
+		if line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// truncate shortens s to at most width runes, marking the cut with "~".
+func truncate(s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "~"
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDurations(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(*Config)
+		wantErr string
+	}{
+		{name: "valid", edit: func(c *Config) { c.RecrawlInterval, c.ShutdownTimeout = "1h", "30s" }},
+		{name: "invalid recrawl interval", edit: func(c *Config) { c.RecrawlInterval = "hourly" }, wantErr: "recrawlInterval"},
+		{name: "zero recrawl interval", edit: func(c *Config) { c.RecrawlInterval = "0s" }, wantErr: "recrawlInterval must be positive"},
+		{name: "invalid startup timeout", edit: func(c *Config) { c.StartupTimeout = "10" }, wantErr: "startupTimeout"},
+		{name: "invalid idle timeout", edit: func(c *Config) { c.OllamaIdleTimeout = "soon" }, wantErr: "ollamaIdleTimeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Config{MaxWorkers: 1, RateLimit: 1, SummarizerType: "ollama", OllamaURL: "http://localhost:11434"}
+			tt.edit(c)
+			err := c.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() = %v, want no error", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() = %v, want an error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,8 +5,11 @@ go 1.22.7
 toolchain go1.23.5
 
 require (
+	github.com/mattn/go-sqlite3 v1.14.33
 	golang.org/x/net v0.34.0
+	golang.org/x/text v0.21.0
 	golang.org/x/time v0.9.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
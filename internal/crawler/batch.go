@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// summaryBatcher holds pages that are ready to be summarized until the
+// batch window has passed since the first of them arrived, then releases
+// them together to compete for summarizer slots. A batch is also released
+// early once every worker is waiting in it, since no more pages can join.
+type summaryBatcher struct {
+	window time.Duration
+	full   int
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	size    int
+	release chan struct{}
+}
+
+func newSummaryBatcher(window time.Duration, full int, logger *slog.Logger) *summaryBatcher {
+	return &summaryBatcher{window: window, full: full, logger: logger}
+}
+
+// wait joins the current batch and blocks until it is released or ctx is
+// cancelled.
+func (b *summaryBatcher) wait(ctx context.Context) error {
+	b.mu.Lock()
+	if b.release == nil {
+		release := make(chan struct{})
+		b.release = release
+		time.AfterFunc(b.window, func() { b.flushBatch(release) })
+	}
+	release := b.release
+	b.size++
+	if b.size >= b.full {
+		b.flushLocked()
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// leave is called when a worker stops, so that the remaining workers'
+// partial batch is released once they are all waiting in it rather than
+// only after the window, and the last batch of a crawl is never held up.
+func (b *summaryBatcher) leave() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.full--
+	if b.size >= b.full {
+		b.flushLocked()
+	}
+}
+
+// flushBatch releases release if it is still the current batch; a timer for
+// a batch that was already flushed does nothing.
+func (b *summaryBatcher) flushBatch(release chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.release == release {
+		b.flushLocked()
+	}
+}
+
+func (b *summaryBatcher) flushLocked() {
+	if b.release == nil {
+		return
+	}
+	b.logger.Debug("Submitting summary batch", "pages", b.size)
+	close(b.release)
+	b.release = nil
+	b.size = 0
+}
@@ -0,0 +1,154 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// brokenLinkLog writes one tab-separated line per broken link: the page it
+// was found on, the link, the status (0 if the request failed) and the
+// error. The file is rewritten on every run.
+type brokenLinkLog struct {
+	mu   sync.Mutex
+	file *os.File
+	seen map[[2]string]bool
+}
+
+func newBrokenLinkLog(path string) (*brokenLinkLog, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create broken links file: %v", err)
+	}
+	if _, err := file.WriteString("source\ttarget\tstatus\terror\n"); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write broken links file: %v", err)
+	}
+	return &brokenLinkLog{file: file, seen: make(map[[2]string]bool)}, nil
+}
+
+// Write records that source links to the broken target, once per pair.
+func (b *brokenLinkLog) Write(source, target string, status int, linkErr error) (bool, error) {
+	msg := ""
+	if linkErr != nil {
+		msg = strings.Join(strings.Fields(linkErr.Error()), " ")
+	} else {
+		msg = http.StatusText(status)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := [2]string{source, target}
+	if b.seen[key] {
+		return false, nil
+	}
+	b.seen[key] = true
+	_, err := fmt.Fprintf(b.file, "%s\t%s\t%d\t%s\n", source, target, status, msg)
+	return true, err
+}
+
+func (b *brokenLinkLog) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.file.Close()
+}
+
+// linkStatus is the cached outcome of checking one link.
+type linkStatus struct {
+	status int
+	err    error
+}
+
+// isBrokenLink reports whether a link answering status (or failing with
+// err) is broken. Cancellation of the crawl itself doesn't count.
+func isBrokenLink(status int, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return status >= 400
+}
+
+// reportBrokenLink adds a broken link to the report, if one is configured.
+func (c *Crawler) reportBrokenLink(source, target string, status int, err error) {
+	if c.brokenLinks == nil || !isBrokenLink(status, err) {
+		return
+	}
+	added, writeErr := c.brokenLinks.Write(source, target, status, err)
+	if writeErr != nil {
+		c.logger.Error("Failed to record broken link", "link", target, "page", source, "error", writeErr)
+	}
+	if added {
+		c.stats.brokenLinks.Add(1)
+	}
+}
+
+// checkLinks sends a HEAD request to each link found on source and reports
+// the broken ones. Each link is requested at most once per crawl.
+func (c *Crawler) checkLinks(ctx context.Context, source string, links []string) {
+	for _, link := range links {
+		if ctx.Err() != nil {
+			return
+		}
+		var outcome linkStatus
+		if cached, ok := c.checkedLinks.Load(link); ok {
+			outcome = cached.(linkStatus)
+		} else {
+			outcome.status, outcome.err = c.headLink(ctx, link)
+			c.checkedLinks.Store(link, outcome)
+		}
+		c.reportBrokenLink(source, link, outcome.status, outcome.err)
+	}
+}
+
+// headLink returns the status of link, retrying with GET for servers that
+// don't support HEAD.
+func (c *Crawler) headLink(ctx context.Context, link string) (int, error) {
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return 0, fmt.Errorf("invalid URL: %v", err)
+	}
+	if err := c.waitForHost(ctx, linkURL); err != nil {
+		return 0, err
+	}
+
+	status, err := c.requestStatus(ctx, http.MethodHead, linkURL)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = c.requestStatus(ctx, http.MethodGet, linkURL)
+	}
+	c.logger.Debug("Checked link", "link", link, "status", status, "error", err)
+	return status, err
+}
+
+func (c *Crawler) requestStatus(ctx context.Context, method string, linkURL *url.URL) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, linkURL.String(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	c.prepareRequest(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// reportBrokenResult reports a crawled page that failed to load as a
+// broken link on the page it was found on.
+func (c *Crawler) reportBrokenResult(result Result) {
+	if result.ParentURL == "" {
+		return
+	}
+	switch {
+	case result.StatusCode >= 400:
+		c.reportBrokenLink(result.ParentURL, result.URL, result.StatusCode, nil)
+	case result.StatusCode == 0 && result.Error != nil:
+		c.reportBrokenLink(result.ParentURL, result.URL, 0, result.Error)
+	}
+}
@@ -0,0 +1,196 @@
+package crawler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+)
+
+// CassetteMode selects whether network responses are recorded to, or
+// replayed from, a cassette file.
+type CassetteMode string
+
+const (
+	// CassetteOff uses the network as usual. It is the default.
+	CassetteOff CassetteMode = "off"
+	// CassetteRecord fetches from the network and saves every response.
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay serves responses from the cassette and fails
+	// requests it has no recording for, without touching the network.
+	CassetteReplay CassetteMode = "replay"
+)
+
+// ParseCassetteMode returns the CassetteMode named by name, defaulting to
+// CassetteOff when name is empty.
+func ParseCassetteMode(name string) (CassetteMode, error) {
+	switch mode := CassetteMode(name); mode {
+	case "":
+		return CassetteOff, nil
+	case CassetteOff, CassetteRecord, CassetteReplay:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown cassette mode %q (want record, replay or off)", name)
+	}
+}
+
+// cassetteEntry is one recorded response.
+type cassetteEntry struct {
+	Key        string      `json:"key"`
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// cassette is an http.RoundTripper that records responses from next, or
+// replays them, keyed by method, URL and request body.
+type cassette struct {
+	path string
+	mode CassetteMode
+	next http.RoundTripper
+
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	entries map[string]*cassetteEntry
+}
+
+// newCassette opens the cassette at path. Replaying requires an existing
+// cassette; recording starts a new one.
+func newCassette(path string, mode CassetteMode, next http.RoundTripper, logger *slog.Logger) (*cassette, error) {
+	if path == "" {
+		return nil, fmt.Errorf("cassette mode %s requires a cassette file", mode)
+	}
+	c := &cassette{
+		path:    path,
+		mode:    mode,
+		next:    next,
+		logger:  logger,
+		entries: make(map[string]*cassetteEntry),
+	}
+	if mode != CassetteReplay {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %v", err)
+	}
+	var entries []*cassetteEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %v", path, err)
+	}
+	for _, entry := range entries {
+		c.entries[entry.Key] = entry
+	}
+	c.logger.Debug("Loaded recorded responses", "count", len(entries), "path", path)
+	return c, nil
+}
+
+// cassetteKey identifies a request. Requests with a body are told apart by
+// its hash.
+func cassetteKey(method, url string, body []byte) string {
+	key := method + " " + url
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		key += " " + hex.EncodeToString(sum[:8])
+	}
+	return key
+}
+
+func (c *cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %v", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	key := cassetteKey(req.Method, req.URL.String(), body)
+
+	if c.mode == CassetteReplay {
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		c.mu.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("no recorded response for %s in cassette %s", key, c.path)
+		}
+		return entry.response(req), nil
+	}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+
+	entry := &cassetteEntry{
+		Key:        key,
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       respBody,
+	}
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	return entry.response(req), nil
+}
+
+func (e *cassetteEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// save writes the recorded responses to the cassette file. Only recording
+// cassettes are written.
+func (c *cassette) save() error {
+	if c.mode != CassetteRecord {
+		return nil
+	}
+
+	c.mu.Lock()
+	entries := make([]*cassetteEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, entry)
+	}
+	c.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %v", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %v", err)
+	}
+	c.logger.Debug("Recorded responses", "count", len(entries), "path", c.path)
+	return nil
+}
@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"webcrawler/internal/summarizer"
+)
+
+// Comparison is the outcome of Compare.
+type Comparison struct {
+	A, B ComparedPage
+	// Differences is the summarizer's account of how the pages differ.
+	Differences string
+}
+
+// ComparedPage is one side of a Comparison.
+type ComparedPage struct {
+	URL     string
+	Title   string
+	Content string
+}
+
+// Compare extracts two pages, such as a page and its staging version, and
+// asks the summarizer for the key differences between them. Nothing is
+// crawled beyond the two pages and no output is written.
+func (c *Crawler) Compare(ctx context.Context, urlA, urlB string) (Comparison, error) {
+	var comparison Comparison
+	var docs [2]summarizer.Document
+	for i, urlStr := range []string{urlA, urlB} {
+		page, input, err := c.extractForCompare(ctx, urlStr)
+		if err != nil {
+			return Comparison{}, err
+		}
+		if i == 0 {
+			comparison.A = page
+		} else {
+			comparison.B = page
+		}
+		docs[i] = summarizer.Document{URL: page.URL, Title: page.Title, Text: input}
+	}
+
+	c.logger.Debug("Comparing pages", "a", urlA, "b", urlB)
+	differences, err := c.withSummarySlot(ctx, func() (string, error) {
+		return c.summarizer.Compare(ctx, docs[0], docs[1])
+	})
+	if err != nil {
+		return Comparison{}, fmt.Errorf("failed to compare pages: %v", err)
+	}
+	comparison.Differences = differences
+	return comparison, nil
+}
+
+// extractForCompare renders and extracts one page for Compare, returning
+// it with its text prepared for the summarizer.
+func (c *Crawler) extractForCompare(ctx context.Context, urlStr string) (ComparedPage, string, error) {
+	pageURL, err := url.Parse(urlStr)
+	if err != nil || !pageURL.IsAbs() {
+		return ComparedPage{}, "", fmt.Errorf("invalid URL to compare: %q", urlStr)
+	}
+	if err := c.waitForHost(ctx, pageURL); err != nil {
+		return ComparedPage{}, "", err
+	}
+
+	profile, _ := c.hostProfile(pageURL)
+	parseResult, err := c.fetcher.Fetch(ctx, urlStr, c.parseOptions(profile))
+	if err != nil {
+		return ComparedPage{}, "", fmt.Errorf("failed to parse %s: %v", urlStr, err)
+	}
+	if parseResult.Text == "" {
+		return ComparedPage{}, "", fmt.Errorf("no content extracted from %s", urlStr)
+	}
+
+	input, _ := c.summaryInput(urlStr, parseResult.Text)
+	page := ComparedPage{
+		URL:     urlStr,
+		Title:   parseResult.Title,
+		Content: parseResult.Text,
+	}
+	return page, input, nil
+}
@@ -0,0 +1,142 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// contentHashEntry is what the sidecar file remembers about a URL: the
+// summarized text and its hash, and the summary with the model and prompt
+// that produced it.
+type contentHashEntry struct {
+	Hash    string `json:"hash"`
+	Summary string `json:"summary"`
+	Content string `json:"content,omitempty"`
+	Depth   int    `json:"depth"`
+	Model   string `json:"model,omitempty"`
+	Prompt  string `json:"prompt,omitempty"` // hash of the prompt
+}
+
+// summaryKey identifies the summarizer settings a summary was made with.
+type summaryKey struct {
+	model  string
+	prompt string
+}
+
+func (e contentHashEntry) key() summaryKey {
+	return summaryKey{model: e.Model, prompt: e.Prompt}
+}
+
+// contentHashStore maps URLs to the hash of their extracted content and the
+// summary produced for it, so unchanged pages can skip the LLM on the next
+// run. It is a lightweight alternative to HTTP cache validation that works
+// even when servers send no ETag or Last-Modified. The stored content also
+// lets summaries be regenerated after a model or prompt change without
+// crawling again. A store without a path is kept in memory only.
+type contentHashStore struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]contentHashEntry
+	dirty   bool
+}
+
+// loadContentHashes reads the sidecar file at path. A missing file yields an
+// empty store.
+func loadContentHashes(path string) (*contentHashStore, error) {
+	store := &contentHashStore{
+		path:    path,
+		entries: make(map[string]contentHashEntry),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read content hash file: %v", err)
+	}
+	if err := json.Unmarshal(data, &store.entries); err != nil {
+		return nil, fmt.Errorf("failed to parse content hash file: %v", err)
+	}
+	return store, nil
+}
+
+func hashContent(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// lookup returns the stored summary for urlStr if its content hash matches
+// and it was made with the same model and prompt.
+func (s *contentHashStore) lookup(urlStr, hash string, key summaryKey) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[urlStr]
+	if !ok || entry.Hash != hash || entry.key() != key || entry.Summary == "" {
+		return "", false
+	}
+	return entry.Summary, true
+}
+
+func (s *contentHashStore) update(urlStr string, entry contentHashEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[urlStr] = entry
+	s.dirty = true
+}
+
+// stale returns the entries whose summary was made with other settings
+// than keyFor returns for their depth. Entries saved without their
+// content cannot be regenerated and are left out.
+func (s *contentHashStore) stale(keyFor func(depth int) summaryKey) map[string]contentHashEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stale := make(map[string]contentHashEntry)
+	for urlStr, entry := range s.entries {
+		if entry.Content != "" && entry.key() != keyFor(entry.Depth) {
+			stale[urlStr] = entry
+		}
+	}
+	return stale
+}
+
+// save writes the store back to disk if it changed, replacing the file
+// atomically.
+func (s *contentHashStore) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty || s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode content hashes: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write content hash file: %v", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write content hash file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write content hash file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace content hash file: %v", err)
+	}
+
+	s.dirty = false
+	return nil
+}
@@ -0,0 +1,324 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"text/template"
+	"time"
+
+	"webcrawler/internal/parser"
+	"webcrawler/internal/summarizer"
+)
+
+func TestNewValidatesSelectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{
+			name:   "valid static selectors",
+			config: Config{RenderMode: RenderStatic, ContentSelectors: []string{"main article", ".post"}, RemoveSelectors: []string{"nav"}},
+		},
+		{
+			name:    "invalid content selector",
+			config:  Config{RenderMode: RenderStatic, ContentSelectors: []string{"article >"}},
+			wantErr: "content selector",
+		},
+		{
+			name:    "invalid remove selector with readability",
+			config:  Config{ExtractMode: parser.ExtractReadability, RemoveSelectors: []string{"[href"}},
+			wantErr: "remove selector",
+		},
+		{
+			name: "invalid host profile selector",
+			config: Config{RenderMode: RenderStatic, HostProfiles: map[string]HostProfile{
+				"example.com": {ContentSelectors: []string{"p:first-child"}},
+			}},
+			wantErr: "host profile example.com",
+		},
+		{
+			// The browser matches selectors itself, with full CSS support.
+			name:   "pseudo-class left to the browser",
+			config: Config{ContentSelectors: []string{"p:first-child"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.SkipSummary = true
+			_, err := New(&tt.config, nil)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("New() = %v, want no error", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("New() = %v, want an error mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// graphFetcher serves a synthetic site whose pages link to each other as
+// links says, by path or by absolute URL for other sites, recording the
+// order pages are requested in.
+type graphFetcher struct {
+	c     *Crawler
+	links map[string][]string
+
+	mu      sync.Mutex
+	visited []string
+	errs    []string
+}
+
+func (f *graphFetcher) Fetch(ctx context.Context, url string, opts parser.ParseOptions) (parser.ParseResult, error) {
+	path := url
+	if rest, ok := strings.CutPrefix(url, "http://example.com"); ok && (rest == "" || rest[0] == '/') {
+		path = "/" + strings.TrimPrefix(rest, "/")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.visited = append(f.visited, path)
+	if err := f.c.checkFrontierCounts(); err != nil {
+		f.errs = append(f.errs, url+": "+err.Error())
+	}
+
+	response := parser.Response{StatusCode: 200, Status: "200 OK", ContentType: "text/html", URL: url}
+	if err := opts.CheckResponse(response); err != nil {
+		return parser.ParseResult{Response: response}, err
+	}
+
+	result := parser.ParseResult{Title: url, Text: "Page " + url, Response: response}
+	for _, link := range f.links[path] {
+		if strings.HasPrefix(link, "/") {
+			link = "http://example.com" + link
+		}
+		result.Links = append(result.Links, parser.Link{URL: link, InContent: true})
+	}
+	return result, nil
+}
+
+// checkFrontierCounts reports when the frontier's counters disagree: every
+// URL in flight is also pending, and a page being fetched is in flight.
+func (c *Crawler) checkFrontierCounts() error {
+	c.frontierMu.Lock()
+	defer c.frontierMu.Unlock()
+	inFlight := 0
+	for depth, n := range c.inFlight {
+		if n <= 0 {
+			return fmt.Errorf("inFlight[%d] = %d", depth, n)
+		}
+		inFlight += n
+	}
+	if inFlight == 0 || inFlight > c.pending {
+		return fmt.Errorf("%d in flight, %d pending", inFlight, c.pending)
+	}
+	return nil
+}
+
+func TestCrawlVisitOrder(t *testing.T) {
+	links := map[string][]string{
+		"/":   {"/a", "/b"},
+		"/a":  {"/a1", "/a2"},
+		"/b":  {"/b1", "/a"},
+		"/a1": {"/", "/a1/deep"},
+	}
+	tests := []struct {
+		strategy string
+		want     []string
+	}{
+		{FrontierBFS, []string{"/", "/a", "/b", "/a1", "/a2", "/b1"}},
+		{FrontierDFS, []string{"/", "/b", "/b1", "/a", "/a2", "/a1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			fetcher := &graphFetcher{links: links}
+			c, err := New(&Config{
+				MaxDepth:         3,
+				MaxWorkers:       1,
+				SkipSummary:      true,
+				IgnoreRobots:     true,
+				IgnoreCrawlDelay: true,
+				AllowedHosts:     []string{"example.com"},
+				FrontierStrategy: tt.strategy,
+			}, nil, WithFetcher(fetcher))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fetcher.c = c
+
+			results, err := c.Crawl(context.Background(), "http://example.com/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range results {
+			}
+
+			if !slices.Equal(fetcher.visited, tt.want) {
+				t.Errorf("visited %v, want %v", fetcher.visited, tt.want)
+			}
+			for _, msg := range fetcher.errs {
+				t.Errorf("frontier counts out of sync at %s", msg)
+			}
+			c.frontierMu.Lock()
+			defer c.frontierMu.Unlock()
+			if c.pending != 0 || len(c.inFlight) != 0 {
+				t.Errorf("after the crawl pending = %d, inFlight = %v; want none", c.pending, c.inFlight)
+			}
+		})
+	}
+}
+
+// endlessFetcher serves a site where every page links to four more, so a
+// crawl only ends when it is cancelled. Pages take delay to load, or until
+// the request's context ends when block is set.
+type endlessFetcher struct {
+	delay   time.Duration
+	block   bool
+	fetched atomic.Int64
+}
+
+func (f *endlessFetcher) Fetch(ctx context.Context, url string, opts parser.ParseOptions) (parser.ParseResult, error) {
+	response := parser.Response{StatusCode: 200, Status: "200 OK", ContentType: "text/html", URL: url}
+	if err := opts.CheckResponse(response); err != nil {
+		return parser.ParseResult{Response: response}, err
+	}
+	if f.block {
+		<-ctx.Done()
+		return parser.ParseResult{Response: response}, ctx.Err()
+	}
+	time.Sleep(f.delay)
+	f.fetched.Add(1)
+
+	result := parser.ParseResult{Title: url, Text: "Page " + url, Response: response}
+	for i := range 4 {
+		result.Links = append(result.Links, parser.Link{URL: fmt.Sprintf("%s/%d", url, i), InContent: true})
+	}
+	return result, nil
+}
+
+func TestCrawlCancel(t *testing.T) {
+	tests := []struct {
+		name     string
+		fetcher  *endlessFetcher
+		shutdown time.Duration
+		close    bool
+	}{
+		// Pages in progress finish within the grace period and are all
+		// delivered.
+		{name: "pages finish", fetcher: &endlessFetcher{delay: time.Millisecond}, shutdown: time.Minute},
+		// Pages that never finish are abandoned when the grace period ends.
+		{name: "grace period ends", fetcher: &endlessFetcher{block: true}, shutdown: 20 * time.Millisecond},
+		// A second interrupt closes the crawler while workers still run.
+		{name: "closed while stopping", fetcher: &endlessFetcher{delay: time.Millisecond}, shutdown: time.Minute, close: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for cancelAfter := 1; cancelAfter <= 10; cancelAfter++ {
+				c, err := New(&Config{
+					MaxDepth:         100,
+					MaxWorkers:       4,
+					SkipSummary:      true,
+					IgnoreRobots:     true,
+					IgnoreCrawlDelay: true,
+					AllowedHosts:     []string{"example.com"},
+					ShutdownTimeout:  tt.shutdown,
+				}, nil, WithFetcher(tt.fetcher))
+				if err != nil {
+					t.Fatal(err)
+				}
+				tt.fetcher.fetched.Store(0)
+
+				ctx, cancel := context.WithCancel(context.Background())
+				results, err := c.Crawl(ctx, "http://example.com/")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.fetcher.block {
+					time.Sleep(time.Millisecond)
+					cancel()
+				}
+
+				received := 0
+				timeout := time.After(10 * time.Second)
+			drain:
+				for {
+					select {
+					case _, ok := <-results:
+						if !ok {
+							break drain
+						}
+						received++
+						if received == cancelAfter {
+							cancel()
+							if tt.close {
+								go c.Close()
+							}
+						}
+					case <-timeout:
+						t.Fatalf("results not closed 10s after cancelling, %d received", received)
+					}
+				}
+				cancel()
+				c.Close()
+
+				if fetched := tt.fetcher.fetched.Load(); !tt.fetcher.block && int64(received) != fetched {
+					t.Errorf("cancelled after %d results: %d received, %d pages fetched", cancelAfter, received, fetched)
+				}
+			}
+		})
+	}
+}
+
+// stubSummarizer is a PageSummarizer that counts the documents it
+// summarizes.
+type stubSummarizer struct {
+	summarizer.PageSummarizer
+	calls atomic.Int64
+}
+
+func (s *stubSummarizer) SummarizeDocument(ctx context.Context, doc summarizer.Document, prompt *template.Template) (string, error) {
+	s.calls.Add(1)
+	return "Summary of " + doc.URL, nil
+}
+
+func (s *stubSummarizer) Model() string             { return "stub" }
+func (s *stubSummarizer) Format() summarizer.Format { return summarizer.FormatParagraph }
+func (s *stubSummarizer) PromptTemplate() string    { return "" }
+
+func TestCrawlSummaryCache(t *testing.T) {
+	dir := t.TempDir()
+	stub := &stubSummarizer{}
+	for run := range 2 {
+		fetcher := &graphFetcher{links: map[string][]string{"/": {"/a"}}}
+		c, err := New(&Config{
+			MaxDepth:         2,
+			MaxWorkers:       1,
+			IgnoreRobots:     true,
+			IgnoreCrawlDelay: true,
+			AllowedHosts:     []string{"example.com"},
+			SummaryCacheDir:  dir,
+		}, stub, WithFetcher(fetcher))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fetcher.c = c
+
+		results, err := c.Crawl(context.Background(), "http://example.com/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for result := range results {
+			if result.Summary == "" || result.SummaryCached != (run == 1) {
+				t.Errorf("run %d: %s summary %q, cached %v", run, result.URL, result.Summary, result.SummaryCached)
+			}
+		}
+		c.Close()
+	}
+	if calls := stub.calls.Load(); calls != 2 {
+		t.Errorf("summarizer called %d times, want once per page", calls)
+	}
+}
@@ -0,0 +1,150 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// failureLog appends every failed URL to a file, one per line, so the file
+// can be fed back in as a seed list. Status and error follow a '#' so they
+// read as a comment when the file is re-ingested.
+//
+// In merge mode the file holds the latest failure of each URL: it is
+// loaded when opened, URLs that fail again replace their line, URLs that
+// now succeed are dropped, and the file is rewritten after every change,
+// through a temporary file so that a crash leaves either the old or the new
+// version.
+type failureLog struct {
+	mu   sync.Mutex
+	file *os.File
+
+	merge bool
+	path  string
+	order []string
+	lines map[string]string
+}
+
+func newFailureLog(path string, mode OutputMode) (*failureLog, error) {
+	if mode == OutputMerge {
+		return loadFailureLog(path)
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if mode == OutputOverwrite {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open failures file: %v", err)
+	}
+	return &failureLog{file: file}, nil
+}
+
+func loadFailureLog(path string) (*failureLog, error) {
+	f := &failureLog{merge: true, path: path, lines: make(map[string]string)}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("failed to open failures file: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		f.set(fields[0], line+"\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read failures file: %v", err)
+	}
+	return f, nil
+}
+
+func (f *failureLog) set(urlStr, line string) {
+	if _, ok := f.lines[urlStr]; !ok {
+		f.order = append(f.order, urlStr)
+	}
+	f.lines[urlStr] = line
+}
+
+// Write records a failed result. It is on disk when Write returns, so
+// partial results survive a crash.
+func (f *failureLog) Write(result Result) error {
+	msg := strings.Join(strings.Fields(result.Error.Error()), " ")
+	line := fmt.Sprintf("%s # status=%d error=%s\n", result.URL, result.StatusCode, msg)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.merge {
+		f.set(result.URL, line)
+		return f.rewrite()
+	}
+	_, err := f.file.WriteString(line)
+	return err
+}
+
+// Resolve forgets an earlier failure of urlStr that has now succeeded. It
+// only has an effect in merge mode.
+func (f *failureLog) Resolve(urlStr string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.lines[urlStr]; !f.merge || !ok {
+		return nil
+	}
+	delete(f.lines, urlStr)
+	if i := slices.Index(f.order, urlStr); i >= 0 {
+		f.order = slices.Delete(f.order, i, i+1)
+	}
+	return f.rewrite()
+}
+
+// rewrite replaces the file with the failures held in merge mode. The
+// caller holds mu.
+func (f *failureLog) rewrite() error {
+	var out strings.Builder
+	for _, urlStr := range f.order {
+		out.WriteString(f.lines[urlStr])
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write failures file: %v", err)
+	}
+	_, err = tmp.WriteString(out.String())
+	if err == nil {
+		err = tmp.Chmod(0o644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write failures file: %v", err)
+	}
+	return nil
+}
+
+// Close closes the file. In merge mode it is already up to date.
+func (f *failureLog) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.merge {
+		return f.file.Close()
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package crawler
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestFailureLogMerge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "failures.txt")
+	earlier := "https://example.com/old # status=500 error=server error\n" +
+		"https://example.com/fixed # status=404 error=not found\n"
+	if err := os.WriteFile(path, []byte(earlier), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := newFailureLog(path, OutputMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every change is on disk straight away, without waiting for Close.
+	if err := f.Write(Result{URL: "https://example.com/new", StatusCode: 503, Error: errors.New("service\nunavailable")}); err != nil {
+		t.Fatal(err)
+	}
+	want := earlier + "https://example.com/new # status=503 error=service unavailable\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("after Write the file holds\n%s\nwant\n%s", got, want)
+	}
+
+	if err := f.Resolve("https://example.com/fixed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Write(Result{URL: "https://example.com/old", StatusCode: 502, Error: errors.New("bad gateway")}); err != nil {
+		t.Fatal(err)
+	}
+	want = "https://example.com/old # status=502 error=bad gateway\n" +
+		"https://example.com/new # status=503 error=service unavailable\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("after Resolve the file holds\n%s\nwant\n%s", got, want)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, path); got != want {
+		t.Errorf("Close changed the file to\n%s", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestFailureLogAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failures.txt")
+	f, err := newFailureLog(path, OutputAppend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := f.Write(Result{URL: "https://example.com/a", Error: errors.New("timeout")}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readFile(t, path), "https://example.com/a # status=0 error=timeout\n"; got != want {
+		t.Errorf("file holds %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package crawler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ResultFilter decides whether a result is kept. Results for which any
+// filter returns false are dropped before reaching sinks or the results
+// channel.
+type ResultFilter func(result Result) bool
+
+// WithResultFilter adds a filter evaluated before each result is output.
+func WithResultFilter(filter ResultFilter) Option {
+	return func(c *Crawler) {
+		c.filters = append(c.filters, filter)
+	}
+}
+
+// ParseResultFilter builds one of the named built-in filters:
+//
+//	no-errors      drop results that failed
+//	has-summary    drop results without a summary
+//	min-words=N    drop pages with fewer than N words of content
+func ParseResultFilter(spec string) (ResultFilter, error) {
+	name, arg, _ := strings.Cut(spec, "=")
+	switch name {
+	case "no-errors":
+		return func(r Result) bool { return r.Error == nil }, nil
+	case "has-summary":
+		return func(r Result) bool { return r.Summary != "" }, nil
+	case "min-words":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid word count in filter %q", spec)
+		}
+		return func(r Result) bool { return len(strings.Fields(r.Content)) >= n }, nil
+	default:
+		return nil, fmt.Errorf("unknown result filter: %s", spec)
+	}
+}
+
+// keep reports whether result passes every filter.
+func (c *Crawler) keep(result Result) bool {
+	for _, filter := range c.filters {
+		if !filter(result) {
+			return false
+		}
+	}
+	return true
+}
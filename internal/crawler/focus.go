@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"strings"
+	"unicode"
+)
+
+// defaultFocusThreshold is the share of query terms a page must contain for
+// its links to be followed in a focused crawl.
+const defaultFocusThreshold = 0.5
+
+// focusTerms splits a focus query into distinct lowercase terms, dropping
+// words too short to be meaningful.
+func focusTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), isTermSeparator) {
+		if len([]rune(word)) < 3 || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+func isTermSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// relevance returns the fraction of terms that occur in text, from 0 to 1.
+func relevance(terms []string, text string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isTermSeparator) {
+		words[word] = true
+	}
+	matched := 0
+	for _, term := range terms {
+		if words[term] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(terms))
+}
+
+// focusScore ranks pending URLs for a focused crawl: links from relevant
+// pages come first, then links whose URL mentions the query, with base
+// breaking ties.
+func focusScore(terms []string, base ScoreFunc) ScoreFunc {
+	return func(item FrontierItem) float64 {
+		score := item.Relevance + 0.5*relevance(terms, item.URL)
+		if base != nil {
+			score += 0.1 * base(item)
+		}
+		return score
+	}
+}
+
+// expandLinks reports whether a page with the given relevance should have
+// its links followed.
+func (c *Crawler) expandLinks(pageRelevance float64) bool {
+	if len(c.focusTerms) == 0 {
+		return true
+	}
+	threshold := c.config.FocusThreshold
+	if threshold <= 0 {
+		threshold = defaultFocusThreshold
+	}
+	return pageRelevance >= threshold
+}
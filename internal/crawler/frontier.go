@@ -0,0 +1,186 @@
+package crawler
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+const (
+	FrontierBFS      = "bfs"
+	FrontierDFS      = "dfs"
+	FrontierPriority = "priority"
+)
+
+// FrontierItem is a URL waiting to be crawled.
+type FrontierItem struct {
+	URL   string
+	Depth int
+
+	// ParentURL is the page URL was found on, empty for seeds.
+	ParentURL string
+
+	// Relevance is the parent page's relevance to the focus query.
+	Relevance float64
+
+	// SubdomainHops counts the links followed since the crawl left
+	// AllowedHosts for another subdomain; see Config.CrossSubdomainHops.
+	SubdomainHops int
+}
+
+// FrontierStrategy decides the order in which pending URLs are crawled.
+// Implementations need not be safe for concurrent use; the crawler
+// serializes access.
+type FrontierStrategy interface {
+	Push(item FrontierItem)
+	Pop() (FrontierItem, bool)
+	Len() int
+}
+
+// LevelFrontier is implemented by strategies that crawl one depth at a
+// time: the crawler hands out none of their URLs deeper than a page still
+// in progress, so every page of depth N is done before depth N+1 starts.
+type LevelFrontier interface {
+	FrontierStrategy
+	// NextDepth returns the depth of the URL Pop would return next.
+	NextDepth() (int, bool)
+}
+
+// ScoreFunc rates a pending URL for the priority frontier; higher scores are
+// crawled first.
+type ScoreFunc func(item FrontierItem) float64
+
+// NewFrontier returns the built-in strategy with the given name. An empty
+// name selects breadth-first order.
+func NewFrontier(name string, score ScoreFunc) (FrontierStrategy, error) {
+	switch name {
+	case "", FrontierBFS, "fifo":
+		return NewFIFOFrontier(), nil
+	case FrontierDFS, "lifo":
+		return NewLIFOFrontier(), nil
+	case FrontierPriority:
+		if score == nil {
+			return nil, fmt.Errorf("priority frontier requires a score function")
+		}
+		return NewPriorityFrontier(score), nil
+	default:
+		return nil, fmt.Errorf("unknown frontier strategy: %s", name)
+	}
+}
+
+// FIFOFrontier crawls URLs in discovery order, giving a breadth-first crawl
+// that finishes each depth before the next.
+type FIFOFrontier struct {
+	items []FrontierItem
+}
+
+func NewFIFOFrontier() *FIFOFrontier {
+	return &FIFOFrontier{}
+}
+
+func (f *FIFOFrontier) Push(item FrontierItem) {
+	f.items = append(f.items, item)
+}
+
+func (f *FIFOFrontier) Pop() (FrontierItem, bool) {
+	if len(f.items) == 0 {
+		return FrontierItem{}, false
+	}
+	item := f.items[0]
+	f.items[0] = FrontierItem{}
+	f.items = f.items[1:]
+	return item, true
+}
+
+func (f *FIFOFrontier) Len() int {
+	return len(f.items)
+}
+
+func (f *FIFOFrontier) NextDepth() (int, bool) {
+	if len(f.items) == 0 {
+		return 0, false
+	}
+	return f.items[0].Depth, true
+}
+
+// LIFOFrontier crawls the most recently discovered URL first, giving a
+// depth-first crawl.
+type LIFOFrontier struct {
+	items []FrontierItem
+}
+
+func NewLIFOFrontier() *LIFOFrontier {
+	return &LIFOFrontier{}
+}
+
+func (f *LIFOFrontier) Push(item FrontierItem) {
+	f.items = append(f.items, item)
+}
+
+func (f *LIFOFrontier) Pop() (FrontierItem, bool) {
+	if len(f.items) == 0 {
+		return FrontierItem{}, false
+	}
+	item := f.items[len(f.items)-1]
+	f.items = f.items[:len(f.items)-1]
+	return item, true
+}
+
+func (f *LIFOFrontier) Len() int {
+	return len(f.items)
+}
+
+// PriorityFrontier crawls the highest-scoring URL first. Equal scores keep
+// discovery order.
+type PriorityFrontier struct {
+	score ScoreFunc
+	queue priorityQueue
+	seq   int
+}
+
+func NewPriorityFrontier(score ScoreFunc) *PriorityFrontier {
+	return &PriorityFrontier{score: score}
+}
+
+func (f *PriorityFrontier) Push(item FrontierItem) {
+	heap.Push(&f.queue, scoredItem{item: item, score: f.score(item), seq: f.seq})
+	f.seq++
+}
+
+func (f *PriorityFrontier) Pop() (FrontierItem, bool) {
+	if f.queue.Len() == 0 {
+		return FrontierItem{}, false
+	}
+	return heap.Pop(&f.queue).(scoredItem).item, true
+}
+
+func (f *PriorityFrontier) Len() int {
+	return f.queue.Len()
+}
+
+type scoredItem struct {
+	item  FrontierItem
+	score float64
+	seq   int
+}
+
+type priorityQueue []scoredItem
+
+func (q priorityQueue) Len() int { return len(q) }
+
+func (q priorityQueue) Less(i, j int) bool {
+	if q[i].score != q[j].score {
+		return q[i].score > q[j].score
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q priorityQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *priorityQueue) Push(x any) { *q = append(*q, x.(scoredItem)) }
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,100 @@
+package crawler
+
+import (
+	"regexp"
+	"strings"
+
+	"webcrawler/internal/langdetect"
+	"webcrawler/internal/parser"
+)
+
+const (
+	GranularityParagraph = "paragraph"
+	GranularitySentence  = "sentence"
+)
+
+var sentenceEnd = regexp.MustCompile(`[.!?]+\s+`)
+
+// primaryLanguageOnly keeps only the parts of text written in its dominant
+// language. Parts whose language can't be detected are kept.
+func primaryLanguageOnly(text, granularity string) string {
+	var parts []string
+	sep := "\n\n"
+	if granularity == GranularitySentence {
+		sep = " "
+		for _, paragraph := range splitParagraphs(text) {
+			parts = append(parts, splitSentences(paragraph)...)
+		}
+	} else {
+		parts = splitParagraphs(text)
+	}
+	if len(parts) < 2 {
+		return text
+	}
+
+	langs := make([]string, len(parts))
+	weights := make(map[string]int)
+	for i, part := range parts {
+		langs[i] = langdetect.Detect(part)
+		if langs[i] != "" {
+			weights[langs[i]] += len(part)
+		}
+	}
+
+	var dominant string
+	for lang, weight := range weights {
+		if dominant == "" || weight > weights[dominant] {
+			dominant = lang
+		}
+	}
+	if dominant == "" {
+		return text
+	}
+
+	kept := parts[:0]
+	for i, part := range parts {
+		if langs[i] == "" || langs[i] == dominant {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+func splitParagraphs(text string) []string {
+	var paragraphs []string
+	for _, p := range strings.Split(text, "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	return paragraphs
+}
+
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		if s := strings.TrimSpace(text[start:loc[1]]); s != "" {
+			sentences = append(sentences, s)
+		}
+		start = loc[1]
+	}
+	if s := strings.TrimSpace(text[start:]); s != "" {
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
+
+// langAllowed reports whether pages in lang may be summarized under
+// AllowedLangs. Pages of unknown language always may.
+func (c *Crawler) langAllowed(lang string) bool {
+	if len(c.config.AllowedLangs) == 0 || lang == "" {
+		return true
+	}
+	for _, allowed := range c.config.AllowedLangs {
+		if parser.NormalizeLang(allowed) == lang {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+
+	"webcrawler/internal/parser"
+)
+
+var defaultSelfLinkParams = []string{"ref"}
+
+// selfLinkKeys returns the comparison keys for every URL a page is known by,
+// typically the requested URL and the final URL after redirects.
+func (c *Crawler) selfLinkKeys(pageURLs ...string) map[string]bool {
+	keys := make(map[string]bool, len(pageURLs))
+	for _, pageURL := range pageURLs {
+		parsed, err := url.Parse(pageURL)
+		if err != nil {
+			continue
+		}
+		keys[c.selfLinkKey(parsed)] = true
+	}
+	return keys
+}
+
+// selfLinkKey reduces a URL to the form used to detect self-links: the URL
+// as normalizeURL leaves it, without ignored query parameters or a trailing
+// slash. Under HashRouting, hash-route fragments are kept.
+func (c *Crawler) selfLinkKey(u *url.URL) string {
+	key := *normalizeURL(u, c.config.StripTrackingParams)
+	if c.config.HashRouting && isHashRoute(u.Fragment) {
+		key.Fragment = u.Fragment
+		key.RawFragment = u.RawFragment
+	}
+
+	params := c.config.SelfLinkParams
+	if params == nil {
+		params = defaultSelfLinkParams
+	}
+	query := key.Query()
+	for _, param := range params {
+		query.Del(param)
+	}
+	key.RawQuery = query.Encode()
+
+	return strings.TrimRight(key.String(), "/")
+}
+
+// isHashRoute reports whether fragment is a single-page app route such as
+// "/docs/intro" or "!/docs/intro" rather than an in-page anchor.
+func isHashRoute(fragment string) bool {
+	return strings.HasPrefix(fragment, "/") || strings.HasPrefix(fragment, "!/")
+}
+
+// registeredDomain returns the registrable domain of host (example.co.uk for
+// www.example.co.uk), or the host itself when it has none, such as an IP.
+func registeredDomain(host string) string {
+	host = strings.ToLower(host)
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// matchHost reports whether host, which may carry a port, matches pattern:
+// either the exact host, or "*.example.com" for example.com and any of its
+// subdomains. Matching ignores case and a trailing dot, and only compares
+// ports when pattern names one, so example.com does not match
+// example.com.attacker.com or notexample.com.
+func matchHost(pattern, host string) bool {
+	pattern = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(pattern)), ".")
+	host = strings.ToLower(host)
+	if !strings.Contains(pattern, ":") {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+		return host == domain || strings.HasSuffix(host, "."+domain)
+	}
+	return host == pattern
+}
+
+// isInternalLink reports whether link shares page's registered domain.
+func isInternalLink(page, link *url.URL) bool {
+	return registeredDomain(page.Hostname()) == registeredDomain(link.Hostname())
+}
+
+const (
+	LinkSourceAll     = "all"
+	LinkSourceContent = "content"
+	LinkSourceNav     = "nav"
+)
+
+func validateLinkSource(source string) error {
+	switch source {
+	case "", LinkSourceAll, LinkSourceContent, LinkSourceNav:
+		return nil
+	default:
+		return fmt.Errorf("unknown link source %q (want all, content or nav)", source)
+	}
+}
+
+// fromLinkSource reports whether link appeared where LinkSource allows
+// links to be followed from.
+func (c *Crawler) fromLinkSource(link parser.Link) bool {
+	switch c.config.LinkSource {
+	case LinkSourceContent:
+		return link.InContent
+	case LinkSourceNav:
+		return link.InNavigation
+	default:
+		return true
+	}
+}
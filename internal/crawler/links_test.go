@@ -0,0 +1,129 @@
+package crawler
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestSelfLinkKey(t *testing.T) {
+	const page = "http://example.com/docs/page"
+	tests := []struct {
+		name   string
+		config Config
+		link   string
+		self   bool
+	}{
+		{name: "fragment", link: page + "#top", self: true},
+		{name: "ref parameter", link: page + "?ref=self", self: true},
+		{name: "ref parameter and fragment", link: page + "?ref=self#top", self: true},
+		{name: "case, default port and trailing slash", link: "HTTP://Example.com:80/docs/page/", self: true},
+		{name: "other parameter", link: page + "?id=2", self: false},
+		{name: "ref kept with other parameters", link: page + "?id=2&ref=self", self: false},
+		{name: "other page with fragment", link: "http://example.com/docs/other#top", self: false},
+		{name: "other host", link: "http://www.example.com/docs/page#top", self: false},
+		{name: "anchor under hash routing", config: Config{HashRouting: true}, link: page + "#top", self: true},
+		{name: "route under hash routing", config: Config{HashRouting: true}, link: page + "#/settings", self: false},
+		{name: "custom ignored parameter", config: Config{SelfLinkParams: []string{"from"}}, link: page + "?from=nav", self: true},
+		{name: "ref not ignored when replaced", config: Config{SelfLinkParams: []string{"from"}}, link: page + "?ref=self", self: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Crawler{config: &tt.config}
+			link, err := url.Parse(tt.link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.selfLinkKeys(page)[c.selfLinkKey(link)]; got != tt.self {
+				t.Errorf("%s is a self-link of %s: got %v, want %v", tt.link, page, got, tt.self)
+			}
+		})
+	}
+}
+
+func TestCrawlSkipsSelfLinks(t *testing.T) {
+	fetcher := &graphFetcher{links: map[string][]string{
+		"/": {"/#top", "/?ref=self", "/other", "/other#top"},
+	}}
+	var selfLinks []string
+	c, err := New(&Config{
+		MaxDepth:         2,
+		MaxWorkers:       1,
+		SkipSummary:      true,
+		IgnoreRobots:     true,
+		IgnoreCrawlDelay: true,
+		AllowedHosts:     []string{"example.com"},
+	}, nil, WithFetcher(fetcher), WithSkipHandler(func(record SkipRecord) {
+		if record.Reason == SkipSelfLink {
+			selfLinks = append(selfLinks, record.URL)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher.c = c
+
+	results, err := c.Crawl(context.Background(), "http://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range results {
+	}
+
+	if want := []string{"/", "/other"}; !slices.Equal(fetcher.visited, want) {
+		t.Errorf("visited %v, want %v", fetcher.visited, want)
+	}
+	if len(selfLinks) != 2 {
+		t.Errorf("self-links skipped: %v, want the #top and ?ref=self links", selfLinks)
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		want    bool
+	}{
+		{"example.com", "example.com", true},
+		{"example.com", "EXAMPLE.com", true},
+		{"example.com", "example.com.", true},
+		{"example.com", "example.com:8080", true},
+		{"example.com", "example.com.attacker.com", false},
+		{"example.com", "notexample.com", false},
+		{"example.com", "sub.example.com", false},
+		{"*.example.com", "example.com", true},
+		{"*.example.com", "sub.example.com", true},
+		{"*.example.com", "a.b.example.com:8443", true},
+		{"*.example.com", "example.com.attacker.com", false},
+		{"*.example.com", "sub.example.com.attacker.com", false},
+		{"*.example.com", "notexample.com", false},
+		{"example.com:8080", "example.com:8080", true},
+		{"example.com:8080", "example.com:9090", false},
+		{"example.com:8080", "example.com", false},
+	}
+	for _, tt := range tests {
+		if got := matchHost(tt.pattern, tt.host); got != tt.want {
+			t.Errorf("matchHost(%q, %q) = %v, want %v", tt.pattern, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestIsAllowedHost(t *testing.T) {
+	c := &Crawler{config: &Config{AllowedHosts: []string{"example.com", "*.docs.example.org"}}, logger: slog.Default()}
+	tests := map[string]bool{
+		"https://example.com/page":                 true,
+		"https://example.com:8080/page":            true,
+		"https://example.com.attacker.com/page":    false,
+		"https://sub.example.com/page":             false,
+		"https://api.docs.example.org/v1":          true,
+		"https://docs.example.org.attacker.com/v1": false,
+		"https://user@attacker.com/example.com":    false,
+	}
+	for urlStr, want := range tests {
+		if got := c.isAllowedHost(urlStr); got != want {
+			t.Errorf("isAllowedHost(%q) = %v, want %v", urlStr, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// trackingParams are query parameters that only identify where a visitor
+// came from. Any parameter starting with utm_ is dropped as well.
+var trackingParams = map[string]bool{
+	"gclid":   true,
+	"dclid":   true,
+	"fbclid":  true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_ga":     true,
+	"_gl":     true,
+}
+
+// normalizeURL returns the form of u used to tell whether two URLs name the
+// same page: the scheme and host are lowercased, a default port and the
+// fragment are removed and the query parameters are sorted by name,
+// keeping their original encoding. With stripTracking, utm_* and other
+// tracking parameters are dropped too.
+func normalizeURL(u *url.URL, stripTracking bool) *url.URL {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	if port := n.Port(); (n.Scheme == "http" && port == "80") || (n.Scheme == "https" && port == "443") {
+		n.Host = strings.TrimSuffix(n.Host, ":"+port)
+	}
+	n.Fragment, n.RawFragment = "", ""
+	n.RawQuery = normalizeQuery(n.RawQuery, stripTracking)
+	n.ForceQuery = false
+	return &n
+}
+
+// normalizeQuery sorts the parameters of rawQuery by name, dropping empty
+// ones and, with stripTracking, tracking parameters. Parameters sharing a
+// name keep their order, since it can matter to the server.
+func normalizeQuery(rawQuery string, stripTracking bool) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var params []string
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		if stripTracking && isTrackingParam(queryParamName(param)) {
+			continue
+		}
+		params = append(params, param)
+	}
+	sort.SliceStable(params, func(i, j int) bool {
+		return queryParamName(params[i]) < queryParamName(params[j])
+	})
+	return strings.Join(params, "&")
+}
+
+// queryParamName returns the unescaped name of a name=value query
+// parameter.
+func queryParamName(param string) string {
+	name, _, _ := strings.Cut(param, "=")
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
+
+func isTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "utm_") || trackingParams[name]
+}
@@ -0,0 +1,30 @@
+package crawler
+
+import "fmt"
+
+// OutputMode tells sinks and output files what to do with output left by
+// a previous run.
+type OutputMode string
+
+const (
+	// OutputAppend keeps previous output and adds to it. It is the default.
+	OutputAppend OutputMode = "append"
+	// OutputOverwrite discards previous output when the crawl starts.
+	OutputOverwrite OutputMode = "overwrite"
+	// OutputMerge keeps previous output but replaces entries for URLs
+	// crawled again, upserting by URL.
+	OutputMerge OutputMode = "merge"
+)
+
+// ParseOutputMode returns the OutputMode named by name, defaulting to
+// OutputAppend when name is empty.
+func ParseOutputMode(name string) (OutputMode, error) {
+	switch mode := OutputMode(name); mode {
+	case "":
+		return OutputAppend, nil
+	case OutputAppend, OutputOverwrite, OutputMerge:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown output mode %q (want overwrite, append or merge)", name)
+	}
+}
@@ -0,0 +1,122 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"webcrawler/internal/parser"
+)
+
+// HostProfile holds per-host request settings, letting a single crawl visit
+// several sites that each need their own credentials or pacing.
+type HostProfile struct {
+	Headers   map[string]string `json:"headers"`
+	Cookies   map[string]string `json:"cookies"`
+	UserAgent string            `json:"user_agent"`
+	// RateLimit is the minimum interval between requests to this host. Zero
+	// falls back to the crawler-wide RateLimit.
+	RateLimit time.Duration `json:"rate_limit"`
+	// TrailingSlash overrides Config.TrailingSlash for this host.
+	TrailingSlash string `json:"trailing_slash"`
+	// ContentSelectors and RemoveSelectors override the crawler-wide
+	// extraction selectors for this host.
+	ContentSelectors []string `json:"content_selectors"`
+	RemoveSelectors  []string `json:"remove_selectors"`
+}
+
+// validateSelectors checks content and remove selectors against what the
+// static selector engine supports.
+func validateSelectors(content, remove []string) error {
+	for _, selector := range content {
+		if err := parser.ValidateSelector(selector); err != nil {
+			return fmt.Errorf("content selector: %v", err)
+		}
+	}
+	for _, selector := range remove {
+		if err := parser.ValidateSelector(selector); err != nil {
+			return fmt.Errorf("remove selector: %v", err)
+		}
+	}
+	return nil
+}
+
+// hostProfile looks up the profile for a URL, matching host:port first and
+// then the bare hostname.
+func (c *Crawler) hostProfile(u *url.URL) (HostProfile, bool) {
+	if len(c.config.HostProfiles) == 0 || u == nil {
+		return HostProfile{}, false
+	}
+	if profile, ok := c.config.HostProfiles[strings.ToLower(u.Host)]; ok {
+		return profile, true
+	}
+	profile, ok := c.config.HostProfiles[strings.ToLower(u.Hostname())]
+	return profile, ok
+}
+
+// userAgentFor returns the user agent sent to u's host.
+func (c *Crawler) userAgentFor(u *url.URL) string {
+	profile, _ := c.hostProfile(u)
+	if userAgent := c.userAgent(profile); userAgent != "" {
+		return userAgent
+	}
+	return parser.DefaultUserAgent
+}
+
+// userAgent returns the user agent configured for the profile's host, or
+// the crawler-wide one; empty when neither is set.
+func (c *Crawler) userAgent(profile HostProfile) string {
+	if profile.UserAgent != "" {
+		return profile.UserAgent
+	}
+	return c.config.UserAgent
+}
+
+// headers returns the headers sent to the profile's host: Config.Headers,
+// overridden by the profile's own. User-Agent is left out; it only comes
+// from userAgent.
+func (c *Crawler) headers(profile HostProfile) map[string]string {
+	if len(c.config.Headers) == 0 && len(profile.Headers) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(c.config.Headers)+len(profile.Headers))
+	for _, source := range []map[string]string{c.config.Headers, profile.Headers} {
+		for name, value := range source {
+			if name = http.CanonicalHeaderKey(name); name != "User-Agent" {
+				headers[name] = value
+			}
+		}
+	}
+	return headers
+}
+
+// warnReservedHeaders reports User-Agent headers that headers drops.
+func (c *Crawler) warnReservedHeaders() {
+	for name := range c.config.Headers {
+		if http.CanonicalHeaderKey(name) == "User-Agent" {
+			c.logger.Warn("Ignoring User-Agent in Headers, set UserAgent instead")
+		}
+	}
+	for host, profile := range c.config.HostProfiles {
+		for name := range profile.Headers {
+			if http.CanonicalHeaderKey(name) == "User-Agent" {
+				c.logger.Warn("Ignoring User-Agent in host profile headers, set its user agent instead", "host", host)
+			}
+		}
+	}
+}
+
+// prepareRequest sets the user agent, headers and cookies configured for
+// req's host on req.
+func (c *Crawler) prepareRequest(req *http.Request) {
+	profile, _ := c.hostProfile(req.URL)
+	req.Header.Set("User-Agent", c.userAgentFor(req.URL))
+	for name, value := range c.headers(profile) {
+		req.Header.Set(name, value)
+	}
+	for name, value := range profile.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+}
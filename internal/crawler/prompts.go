@@ -0,0 +1,143 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"text/template"
+
+	"webcrawler/internal/summarizer"
+)
+
+// formatCustom is reported as Result.SummaryFormat for pages summarized
+// with a DepthPrompts override.
+const formatCustom = "custom"
+
+func compileDepthPrompts(prompts map[int]string) (map[int]*template.Template, error) {
+	if len(prompts) == 0 {
+		return nil, nil
+	}
+	compiled := make(map[int]*template.Template, len(prompts))
+	for depth, text := range prompts {
+		tmpl, err := summarizer.ParsePrompt(text)
+		if err != nil {
+			return nil, fmt.Errorf("depth %d: %v", depth, err)
+		}
+		compiled[depth] = tmpl
+	}
+	return compiled, nil
+}
+
+// checkSummarizer runs the startup check: with a StartupTimeout the model
+// is also loaded, otherwise only the server's health is checked.
+func (c *Crawler) checkSummarizer() error {
+	if c.config.StartupTimeout <= 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), summarizerCheckTimeout)
+		defer cancel()
+		return c.summarizer.HealthCheck(ctx)
+	}
+
+	c.logger.Debug("Waiting for the summarizer to be ready", "timeout", c.config.StartupTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.StartupTimeout)
+	defer cancel()
+	return c.summarizer.Ready(ctx)
+}
+
+// summarize summarizes text from a page at depth, using the depth's prompt
+// override when there is one. At most SummarizeConcurrency calls run at
+// once; the rest wait here rather than queueing inside the backend.
+func (c *Crawler) summarize(ctx context.Context, text string, depth int) (string, error) {
+	return c.summarizePage(ctx, summarizer.Document{Text: text}, depth)
+}
+
+// summarizePage is like summarize but gives the summarizer the page's title
+// and description along with its text.
+func (c *Crawler) summarizePage(ctx context.Context, doc summarizer.Document, depth int) (string, error) {
+	summary, err := c.withSummarySlot(ctx, func() (string, error) {
+		return c.summarizer.SummarizeDocument(ctx, doc, c.depthPrompts[depth])
+	})
+	if err != nil {
+		c.stats.summaryErrors.Add(1)
+	} else {
+		c.stats.summaries.Add(1)
+	}
+	return summary, err
+}
+
+// withSummarySlot runs generate once one of the SummarizeConcurrency slots
+// is free.
+func (c *Crawler) withSummarySlot(ctx context.Context, generate func() (string, error)) (string, error) {
+	select {
+	case c.summarizeSem <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-c.summarizeSem }()
+
+	return generate()
+}
+
+// critique has the model rate result's summary of text and records the
+// outcome on result. Failures are logged and leave the page unrated.
+func (c *Crawler) critique(ctx context.Context, result *Result, text string) {
+	var critique summarizer.Critique
+	_, err := c.withSummarySlot(ctx, func() (string, error) {
+		var err error
+		critique, err = c.summarizer.Critique(ctx, text, result.Summary)
+		return "", err
+	})
+	if err != nil {
+		c.logger.Warn("Failed to critique summary", "url", result.URL, "error", err)
+		return
+	}
+
+	result.SummaryScore = critique.Score
+	result.SourceUnsummarizable = critique.Unsummarizable
+	result.CritiqueReason = critique.Reason
+	c.logger.Debug("Critiqued summary", "url", result.URL, "score", critique.Score, "unsummarizable", critique.Unsummarizable, "reason", critique.Reason)
+	if critique.Unsummarizable {
+		c.logger.Warn("Source looks too short or garbled to summarize", "url", result.URL, "reason", critique.Reason)
+	}
+}
+
+// summaryFormat names the prompt used for pages at depth.
+func (c *Crawler) summaryFormat(depth int) string {
+	if _, ok := c.depthPrompts[depth]; ok || c.summarizer.PromptTemplate() != "" {
+		return formatCustom
+	}
+	return string(c.summarizer.Format())
+}
+
+// structureSummary parses a FormatJSON summary into result.Structured,
+// replacing the raw response in result.Summary with its text layout. A
+// summary that can't be parsed is left as it is.
+func (c *Crawler) structureSummary(result *Result) {
+	structured, err := summarizer.ParseStructuredSummary(result.Summary)
+	if err != nil {
+		c.logger.Warn("Failed to parse structured summary", "url", result.URL, "error", err)
+		return
+	}
+	result.Structured = &structured
+	result.Summary = structured.String()
+}
+
+// withSummaryCache wraps s in a CachingSummarizer kept in dir, unless dir
+// is empty or there is no summarizer.
+func withSummaryCache(s summarizer.PageSummarizer, dir string) summarizer.PageSummarizer {
+	if dir == "" || s == nil {
+		return s
+	}
+	return summarizer.NewCachingSummarizer(s, dir)
+}
+
+// summaryKey identifies the model and prompt used for pages at depth, so
+// that changing either regenerates stored summaries.
+func (c *Crawler) summaryKey(depth int) summaryKey {
+	prompt, ok := c.config.DepthPrompts[depth]
+	if !ok {
+		prompt = c.summarizer.PromptTemplate()
+	}
+	if prompt == "" {
+		prompt = string(c.summarizer.Format())
+	}
+	return summaryKey{model: c.summarizer.Model(), prompt: hashContent(prompt)}
+}
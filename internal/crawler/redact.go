@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const defaultRedactPlaceholder = "[REDACTED]"
+
+// builtinRedactPatterns can be named in RedactPatterns instead of writing
+// the expressions out.
+var builtinRedactPatterns = map[string]string{
+	"email": `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+	"phone": `(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)|\d{2,4})[\s.-]?\d{3,4}[\s.-]?\d{3,4}\b`,
+	"card":  `\b(?:\d[ -]?){12,18}\d\b`,
+	"token": `(?i)\bbearer\s+[A-Za-z0-9._~+/-]{15,}[A-Za-z0-9_~+/-]=*|\b(?:sk|pk|rk|ghp|gho|ghs|xox[abprs])[-_][A-Za-z0-9_-]{16,}|\bAKIA[0-9A-Z]{16}\b`,
+}
+
+// defaultRedactPatterns are redacted when RedactPatterns is nil; "token"
+// and "card" have to be asked for. An empty, non-nil list turns redaction
+// off.
+var defaultRedactPatterns = []string{"email", "phone"}
+
+// compileRedactPatterns compiles RedactPatterns, expanding the names of
+// built-in patterns. Cards and tokens are listed before phones so that
+// their digits aren't partly taken for phone numbers.
+func compileRedactPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	if patterns == nil {
+		patterns = defaultRedactPatterns
+	}
+	var compiled []*regexp.Regexp
+	for _, name := range []string{"card", "token", "email", "phone"} {
+		for _, pattern := range patterns {
+			if pattern == name {
+				compiled = append(compiled, regexp.MustCompile(builtinRedactPatterns[name]))
+				break
+			}
+		}
+	}
+	for _, pattern := range patterns {
+		if _, ok := builtinRedactPatterns[pattern]; ok {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %v", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// redact replaces every match of the configured patterns in text with the
+// placeholder and returns the result with the number of replacements.
+func (c *Crawler) redact(text string) (string, int) {
+	placeholder := c.config.RedactPlaceholder
+	if placeholder == "" {
+		placeholder = defaultRedactPlaceholder
+	}
+
+	count := 0
+	for _, re := range c.redactPatterns {
+		text = re.ReplaceAllStringFunc(text, func(string) string {
+			count++
+			return placeholder
+		})
+	}
+	return text, count
+}
@@ -0,0 +1,17 @@
+package crawler
+
+import "fmt"
+
+const (
+	RenderBrowser = "browser"
+	RenderStatic  = "static"
+)
+
+func validateRenderMode(mode string) error {
+	switch mode {
+	case "", RenderBrowser, RenderStatic:
+		return nil
+	default:
+		return fmt.Errorf("unknown render mode %q (want browser or static)", mode)
+	}
+}
@@ -0,0 +1,69 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// storeSummary remembers the summary of a page in the content hash file,
+// along with the text it was made from and the settings that made it.
+func (c *Crawler) storeSummary(urlStr string, depth int, content, hash, summary string) {
+	key := c.summaryKey(depth)
+	c.contentHashes.update(urlStr, contentHashEntry{
+		Hash:    hash,
+		Summary: summary,
+		Content: content,
+		Depth:   depth,
+		Model:   key.model,
+		Prompt:  key.prompt,
+	})
+}
+
+// ResummarizeChanged regenerates, without crawling, the summaries in the
+// content hash file that were made with a different model or prompt than
+// the current ones, passing each new result to handle. It returns the
+// number of summaries regenerated.
+func (c *Crawler) ResummarizeChanged(ctx context.Context, handle func(Result)) (int, error) {
+	if c.contentHashes == nil {
+		return 0, fmt.Errorf("resummarizing requires a content hash file")
+	}
+
+	stale := c.contentHashes.stale(c.summaryKey)
+	urls := make([]string, 0, len(stale))
+	for urlStr := range stale {
+		urls = append(urls, urlStr)
+	}
+	sort.Strings(urls)
+	c.logger.Debug("Stored summaries made with other settings", "count", len(urls))
+
+	done := 0
+	for _, urlStr := range urls {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+		entry := stale[urlStr]
+		result := Result{
+			URL:       urlStr,
+			Depth:     entry.Depth,
+			Content:   entry.Content,
+			CrawledAt: time.Now(),
+		}
+
+		summary, err := c.summarize(ctx, entry.Content, entry.Depth)
+		if err != nil {
+			result.Error = fmt.Errorf("failed to generate summary: %v", err)
+		} else {
+			result.Summary = summary
+			result.SummaryFormat = c.summaryFormat(entry.Depth)
+			c.storeSummary(urlStr, entry.Depth, entry.Content, entry.Hash, summary)
+			done++
+		}
+
+		if c.record(result) {
+			handle(result)
+		}
+	}
+	return done, nil
+}
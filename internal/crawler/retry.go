@@ -0,0 +1,159 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"webcrawler/internal/parser"
+)
+
+const (
+	defaultMaxRetries      = 2
+	defaultMaxFetchRetries = 2
+
+	// defaultRetryBackoff is the first wait before a retry when the
+	// response has no usable Retry-After header; it doubles with each
+	// retry.
+	defaultRetryBackoff = 2 * time.Second
+
+	// retryJitter randomizes each backoff by up to this fraction either
+	// way, so that pages which failed together don't retry together.
+	retryJitter = 0.5
+
+	// maxRetryWait is the longest Retry-After the crawler waits for; a
+	// host asking for more is given up on.
+	maxRetryWait = 5 * time.Minute
+)
+
+// maxRetries returns how many times a 429 or 503 response is retried.
+func (c *Crawler) maxRetries() int {
+	switch {
+	case c.config.MaxRetries < 0:
+		return 0
+	case c.config.MaxRetries == 0:
+		return defaultMaxRetries
+	}
+	return c.config.MaxRetries
+}
+
+// maxFetchRetries returns how many times a fetch that failed transiently
+// is retried.
+func (c *Crawler) maxFetchRetries() int {
+	switch {
+	case c.config.MaxFetchRetries < 0:
+		return 0
+	case c.config.MaxFetchRetries == 0:
+		return defaultMaxFetchRetries
+	}
+	return c.config.MaxFetchRetries
+}
+
+// backoff returns the wait before the given retry, counting from 1:
+// RetryBackoff doubled for each earlier retry, up to maxRetryWait, and
+// randomized by retryJitter.
+func (c *Crawler) backoff(retry int) time.Duration {
+	base := c.config.RetryBackoff
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+	return jitterInterval(min(base<<min(retry-1, 16), maxRetryWait), retryJitter)
+}
+
+// retryableStatus reports whether status asks the client to try again
+// later.
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// transientBrowserErrors are the Chromium network error codes worth
+// retrying. The browser reports them only in its error messages, as
+// net::ERR_CONNECTION_RESET and the like.
+var transientBrowserErrors = []string{
+	"net::ERR_TIMED_OUT",
+	"net::ERR_CONNECTION_TIMED_OUT",
+	"net::ERR_CONNECTION_RESET",
+	"net::ERR_CONNECTION_REFUSED",
+	"net::ERR_CONNECTION_CLOSED",
+	"net::ERR_EMPTY_RESPONSE",
+	"net::ERR_NAME_RESOLUTION_FAILED",
+	"net::ERR_NETWORK_CHANGED",
+}
+
+// transientFailure reports whether a fetch is worth trying again: the
+// response was rejected (checkErr) with a 5xx status, or the fetch failed
+// with err at the network level, by timing out, losing its connection or
+// failing a DNS lookup temporarily. A cancelled ctx is never retried, and
+// neither are 4xx responses, pages that failed to parse, downloads or a
+// WaitForSelector that timed out.
+func transientFailure(ctx context.Context, checkErr, err error, status int) bool {
+	switch {
+	case ctx.Err() != nil:
+		return false
+	case checkErr != nil:
+		return status >= 500 && status <= 599
+	case err == nil, errors.Is(err, parser.ErrSelectorNotFound), errors.Is(err, parser.ErrDownload):
+		return false
+	case errors.Is(err, parser.ErrNavigationTimeout):
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	// A host that doesn't exist stays that way; only a failing resolver is
+	// worth another try.
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "read" || opErr.Op == "write") {
+		return true
+	}
+	switch {
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+	msg := err.Error()
+	for _, code := range transientBrowserErrors {
+		if strings.Contains(msg, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryDelay returns how long to wait before retrying a request answered
+// with the Retry-After header retryAfter. It honors the header's delay in
+// seconds or its HTTP date and otherwise waits backoff.
+func retryDelay(retryAfter string, backoff time.Duration, now time.Time) time.Duration {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(retryAfter); err == nil {
+		return max(when.Sub(now), 0)
+	}
+	return backoff
+}
+
+// sleepContext waits for d, returning early with ctx's error if it is
+// cancelled first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
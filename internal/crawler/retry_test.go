@@ -0,0 +1,92 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"webcrawler/internal/parser"
+)
+
+func TestTransientFailure(t *testing.T) {
+	fetchErr := func(err error) error {
+		return fmt.Errorf("failed to fetch URL: %w", &url.Error{Op: "Get", URL: "http://example.com/", Err: err})
+	}
+	tests := []struct {
+		name     string
+		checkErr error
+		err      error
+		status   int
+		want     bool
+	}{
+		{name: "success", want: false},
+		{name: "server error", checkErr: errors.New("rejected"), status: 503, want: true},
+		{name: "client error", checkErr: errors.New("rejected"), status: 404, want: false},
+		{name: "connection refused", err: fetchErr(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), want: true},
+		{name: "connection reset", err: fetchErr(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), want: true},
+		{name: "reset without an OpError", err: fmt.Errorf("failed to read response body: %w", syscall.ECONNRESET), want: true},
+		{name: "timeout", err: fetchErr(os.ErrDeadlineExceeded), want: true},
+		{name: "unexpected EOF", err: fetchErr(io.ErrUnexpectedEOF), want: true},
+		{name: "temporary DNS failure", err: fetchErr(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true}}), want: true},
+		{name: "unknown host", err: fetchErr(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}}), want: false},
+		{name: "navigation timeout", err: fmt.Errorf("%w: Timeout 30000ms exceeded", parser.ErrNavigationTimeout), want: true},
+		{name: "browser connection reset", err: errors.New("failed to navigate to URL: net::ERR_CONNECTION_RESET at http://example.com/"), want: true},
+		{name: "browser unknown host", err: errors.New("failed to navigate to URL: net::ERR_NAME_NOT_RESOLVED at http://example.com/"), want: false},
+		{name: "message alone", err: errors.New("connection reset by peer"), want: false},
+		{name: "selector timeout", err: fmt.Errorf("%w: timed out", parser.ErrSelectorNotFound), want: false},
+		{name: "download", err: fmt.Errorf("%w: net::ERR_ABORTED", parser.ErrDownload), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transientFailure(context.Background(), tt.checkErr, tt.err, tt.status); got != tt.want {
+				t.Errorf("transientFailure(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if transientFailure(ctx, nil, fetchErr(os.ErrDeadlineExceeded), 0) {
+		t.Error("a cancelled fetch is retried")
+	}
+}
+
+func TestTransientFailureStaticFetcher(t *testing.T) {
+	// A server that hangs up without answering.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn.Close()
+	}))
+	defer server.Close()
+
+	_, err := parser.NewStaticFetcher(nil).Fetch(context.Background(), server.URL, parser.ParseOptions{})
+	if err == nil {
+		t.Fatal("fetch succeeded")
+	}
+	if !transientFailure(context.Background(), nil, err, 0) {
+		t.Errorf("hang-up not retried: %v", err)
+	}
+
+	// Nothing listens on a closed server's address.
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	_, err = parser.NewStaticFetcher(nil).Fetch(context.Background(), closed.URL, parser.ParseOptions{})
+	if err == nil {
+		t.Fatal("fetch succeeded")
+	}
+	if !transientFailure(context.Background(), nil, err, 0) {
+		t.Errorf("refused connection not retried: %v", err)
+	}
+}
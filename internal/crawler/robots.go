@@ -0,0 +1,214 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowedByRobots is the Result.Error of URLs that robots.txt does
+// not allow the crawler to fetch.
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+// robotsGroup is a set of directives that apply to the listed user agents.
+type robotsGroup struct {
+	agents     []string
+	crawlDelay time.Duration
+	rules      []robotsRule
+}
+
+// robotsRule is an Allow or Disallow line. Patterns may use * for any
+// characters and end in $ to anchor the match at the end of the path.
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+type robotsFile struct {
+	groups []*robotsGroup
+}
+
+// robotsEntry caches a host's robots.txt; once guards the single fetch.
+type robotsEntry struct {
+	once  sync.Once
+	robot *robotsFile
+}
+
+// robotsFetchTimeout bounds the fetch of a host's robots.txt.
+const robotsFetchTimeout = 10 * time.Second
+
+// robotsFor returns the parsed robots.txt for u's host, fetching it on first
+// use. A missing or unreadable robots.txt yields an empty file.
+func (c *Crawler) robotsFor(ctx context.Context, u *url.URL) *robotsFile {
+	host := strings.ToLower(u.Host)
+	value, _ := c.robots.LoadOrStore(host, &robotsEntry{})
+	entry := value.(*robotsEntry)
+	entry.once.Do(func() {
+		// The result is cached for every later caller, so the fetch must
+		// not fail, leaving the host allowed everything, just because the
+		// first caller's context was cancelled.
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), robotsFetchTimeout)
+		defer cancel()
+		entry.robot = c.fetchRobots(fetchCtx, u)
+	})
+	return entry.robot
+}
+
+func (c *Crawler) fetchRobots(ctx context.Context, u *url.URL) *robotsFile {
+	robotsURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL.String(), nil)
+	if err != nil {
+		return &robotsFile{}
+	}
+	c.prepareRequest(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.logger.Debug("Failed to fetch robots.txt", "url", robotsURL.String(), "error", err)
+		return &robotsFile{}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		c.logger.Debug("No robots.txt", "host", u.Host, "status", resp.StatusCode)
+		return &robotsFile{}
+	}
+
+	return parseRobots(resp.Body)
+}
+
+func parseRobots(r io.Reader) *robotsFile {
+	robots := &robotsFile{}
+	var group *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			if !inAgents {
+				group = &robotsGroup{}
+				robots.groups = append(robots.groups, group)
+			}
+			group.agents = append(group.agents, strings.ToLower(value))
+			inAgents = true
+			continue
+		}
+		inAgents = false
+		if group == nil {
+			continue
+		}
+
+		switch key {
+		case "allow", "disallow":
+			// An empty Disallow allows everything, the same as no rule.
+			if value != "" {
+				group.rules = append(group.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				group.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	return robots
+}
+
+// group returns the directives for userAgent: the first group naming a token
+// contained in the user agent, falling back to the "*" group.
+func (r *robotsFile) group(userAgent string) *robotsGroup {
+	userAgent = strings.ToLower(userAgent)
+	var fallback *robotsGroup
+	for _, group := range r.groups {
+		for _, agent := range group.agents {
+			if agent == "*" {
+				if fallback == nil {
+					fallback = group
+				}
+			} else if agent != "" && strings.Contains(userAgent, agent) {
+				return group
+			}
+		}
+	}
+	return fallback
+}
+
+// crawlDelay returns the Crawl-delay that applies to userAgent, if any.
+func (r *robotsFile) crawlDelay(userAgent string) time.Duration {
+	if group := r.group(userAgent); group != nil {
+		return group.crawlDelay
+	}
+	return 0
+}
+
+// allowed reports whether userAgent may fetch u. The longest matching rule
+// wins, with Allow winning ties; URLs no rule matches are allowed.
+func (r *robotsFile) allowed(userAgent string, u *url.URL) bool {
+	group := r.group(userAgent)
+	if group == nil {
+		return true
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	allowed, longest := true, -1
+	for _, rule := range group.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allowed, longest = rule.allow, len(rule.pattern)
+		}
+	}
+	return allowed
+}
+
+// matchRobotsPattern reports whether a robots.txt path pattern matches
+// path: a prefix match in which * matches any run of characters and a
+// trailing $ requires the match to reach the end of path.
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
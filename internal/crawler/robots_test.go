@@ -0,0 +1,43 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestRobotsForCancelledContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	c, err := New(&Config{SkipSummary: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// The first page of a host is requested as the crawl is cancelled; its
+	// robots.txt must still be fetched and cached for the pages after it.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	private, _ := url.Parse(server.URL + "/private/page")
+	if c.robotsFor(ctx, private).allowed(c.userAgentFor(private), private) {
+		t.Error("page disallowed by robots.txt allowed after a cancelled fetch")
+	}
+
+	public, _ := url.Parse(server.URL + "/public")
+	if !c.robotsFor(context.Background(), public).allowed(c.userAgentFor(public), public) {
+		t.Error("page robots.txt allows is disallowed")
+	}
+	if c.robotsFor(context.Background(), private).allowed(c.userAgentFor(private), private) {
+		t.Error("cached robots.txt allows a disallowed page")
+	}
+}
@@ -0,0 +1,118 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SchedulerStatus describes the scheduler's last completed run and when the
+// next one starts.
+type SchedulerStatus struct {
+	Runs          int
+	NextRun       time.Time
+	LastRunStart  time.Time
+	LastRunEnd    time.Time
+	LastRunPages  int
+	LastRunErrors int
+}
+
+// Scheduler re-crawls a fixed set of seeds on an interval, turning a
+// one-shot crawl into continuous monitoring.
+type Scheduler struct {
+	crawler  *Crawler
+	seeds    []string
+	interval time.Duration
+
+	mu     sync.Mutex
+	status SchedulerStatus
+}
+
+// NewScheduler creates a scheduler that crawls seeds every interval. Pages
+// whose content is unchanged since the previous run reuse its summary;
+// without a ContentHashFile the hashes are kept in memory between runs.
+func NewScheduler(crawler *Crawler, interval time.Duration, seeds ...string) *Scheduler {
+	if crawler.contentHashes == nil {
+		crawler.contentHashes = &contentHashStore{entries: make(map[string]contentHashEntry)}
+	}
+	return &Scheduler{
+		crawler:  crawler,
+		seeds:    seeds,
+		interval: interval,
+	}
+}
+
+// Status returns a snapshot of the scheduler's progress. It is safe to call
+// while the scheduler runs.
+func (s *Scheduler) Status() SchedulerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// Run crawls the seeds immediately and then once per interval, passing every
+// result to handle, until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context, handle func(Result)) error {
+	for {
+		start := time.Now()
+		pages, errors := 0, 0
+
+		s.crawler.resetVisited()
+		results, err := s.crawler.Crawl(ctx, s.seeds...)
+		if err != nil {
+			return err
+		}
+		for result := range results {
+			pages++
+			if result.Error != nil {
+				errors++
+			}
+			handle(result)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.crawler.contentHashes.save(); err != nil {
+			s.crawler.logger.Warn("Failed to save content hashes", "error", err)
+		}
+
+		next := time.Now().Add(s.interval)
+		s.mu.Lock()
+		s.status = SchedulerStatus{
+			Runs:          s.status.Runs + 1,
+			NextRun:       next,
+			LastRunStart:  start,
+			LastRunEnd:    time.Now(),
+			LastRunPages:  pages,
+			LastRunErrors: errors,
+		}
+		status := s.status
+		s.mu.Unlock()
+
+		s.crawler.logger.Info("Re-crawl finished", "run", status.Runs, "pages", pages, "errors", errors,
+			"duration", status.LastRunEnd.Sub(start).Round(time.Millisecond), "next_run", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// resetVisited forgets every visited URL, including those in the store, so
+// the next crawl fetches every page again. Content hashes are kept.
+func (c *Crawler) resetVisited() {
+	c.storeReset.Store(true)
+	c.visited.Range(func(key, _ any) bool {
+		c.visited.Delete(key)
+		return true
+	})
+	c.checkedLinks.Range(func(key, _ any) bool {
+		c.checkedLinks.Delete(key)
+		return true
+	})
+}
@@ -0,0 +1,40 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+)
+
+// subdomainHops returns how many cross-subdomain hops it takes to reach
+// host from a page that was itself reached in parentHops: 0 for hosts
+// within AllowedHosts, one more than parentHops for other subdomains of
+// their registered domains, and -1 for everything else, including all
+// hosts when CrossSubdomainHops is off.
+func (c *Crawler) subdomainHops(parentHops int, host string) int {
+	if len(c.config.AllowedHosts) == 0 || c.allowedHost(host) {
+		return 0
+	}
+	if c.config.CrossSubdomainHops <= 0 {
+		return -1
+	}
+	domain := registeredDomain(host)
+	for _, pattern := range c.config.AllowedHosts {
+		if registeredDomain(strings.TrimPrefix(pattern, "*.")) == domain {
+			return parentHops + 1
+		}
+	}
+	return -1
+}
+
+// inScope reports whether a page fetched from u, reached in hops
+// cross-subdomain hops, may be crawled.
+func (c *Crawler) inScope(u *url.URL, hops int) bool {
+	if c.isAllowedHost(u.String()) {
+		return true
+	}
+	if c.subdomainHops(hops, u.Hostname()) < 0 {
+		return false
+	}
+	// A page redirected off AllowedHosts has taken one hop already.
+	return max(hops, 1) <= c.config.CrossSubdomainHops
+}
@@ -0,0 +1,77 @@
+package crawler
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestCrawlSkipsExternalLinks(t *testing.T) {
+	links := map[string][]string{
+		"/": {
+			"/a",
+			"https://other.example/x",
+			"http://example.com.attacker.com/y",
+			"http://docs.example.com/guide",
+		},
+	}
+	tests := []struct {
+		name        string
+		hops        int
+		wantVisited []string
+		wantSkipped []string
+	}{
+		{
+			name:        "no subdomain hops",
+			wantVisited: []string{"/", "/a"},
+			wantSkipped: []string{"http://docs.example.com/guide", "http://example.com.attacker.com/y", "https://other.example/x"},
+		},
+		{
+			name:        "one subdomain hop",
+			hops:        1,
+			wantVisited: []string{"/", "/a", "http://docs.example.com/guide"},
+			wantSkipped: []string{"http://example.com.attacker.com/y", "https://other.example/x"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &graphFetcher{links: links}
+			var skipped []string
+			c, err := New(&Config{
+				MaxDepth:           2,
+				MaxWorkers:         1,
+				SkipSummary:        true,
+				IgnoreRobots:       true,
+				IgnoreCrawlDelay:   true,
+				AllowedHosts:       []string{"example.com"},
+				CrossSubdomainHops: tt.hops,
+			}, nil, WithFetcher(fetcher), WithSkipHandler(func(record SkipRecord) {
+				if record.Reason == SkipHost {
+					skipped = append(skipped, record.URL)
+				}
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fetcher.c = c
+
+			results, err := c.Crawl(context.Background(), "http://example.com/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range results {
+			}
+
+			if !slices.Equal(fetcher.visited, tt.wantVisited) {
+				t.Errorf("visited %v, want %v", fetcher.visited, tt.wantVisited)
+			}
+			slices.Sort(skipped)
+			if !slices.Equal(skipped, tt.wantSkipped) {
+				t.Errorf("skipped as off-site %v, want %v", skipped, tt.wantSkipped)
+			}
+			if stats := c.Stats(); stats.Failed != 0 {
+				t.Errorf("%d pages failed (%v), want none", stats.Failed, stats.Errors)
+			}
+		})
+	}
+}
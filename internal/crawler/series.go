@@ -0,0 +1,159 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// seriesPart is one page of a multi-part series awaiting a merged summary.
+type seriesPart struct {
+	url     string
+	title   string
+	content string
+	depth   int
+}
+
+// compileMergePatterns compiles the series patterns. Each must capture the
+// series key in its first group.
+func compileMergePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid merge pattern %q: %v", pattern, err)
+		}
+		if re.NumSubexp() < 1 {
+			return nil, fmt.Errorf("merge pattern %q must capture the series key in a group", pattern)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// seriesKey returns the series urlStr belongs to, if any merge pattern
+// matches it.
+func (c *Crawler) seriesKey(urlStr string) (string, bool) {
+	for _, re := range c.mergePatterns {
+		if match := re.FindStringSubmatch(urlStr); match != nil {
+			return re.String() + "|" + match[1], true
+		}
+	}
+	return "", false
+}
+
+func (c *Crawler) addSeriesPart(key string, part seriesPart) {
+	c.seriesMu.Lock()
+	defer c.seriesMu.Unlock()
+	c.series[key] = append(c.series[key], part)
+}
+
+// summarizeSeries produces one result per collected series and clears the
+// collection. Each part is summarized on its own (map) and the partial
+// summaries are then summarized together (reduce); a single-part series is
+// summarized directly.
+func (c *Crawler) summarizeSeries(ctx context.Context) []Result {
+	c.seriesMu.Lock()
+	series := c.series
+	c.series = make(map[string][]seriesPart)
+	c.seriesMu.Unlock()
+
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var results []Result
+	for _, key := range keys {
+		parts := series[key]
+		sort.Slice(parts, func(i, j int) bool { return naturalLess(parts[i].url, parts[j].url) })
+
+		result := Result{
+			URL:       parts[0].url,
+			Title:     parts[0].title,
+			Depth:     parts[0].depth,
+			SeriesKey: key,
+		}
+		var contents []string
+		for _, part := range parts {
+			result.SeriesURLs = append(result.SeriesURLs, part.url)
+			contents = append(contents, part.content)
+			if part.depth < result.Depth {
+				result.Depth = part.depth
+			}
+		}
+		result.Content = strings.Join(contents, "\n\n")
+
+		c.logger.Debug("Summarizing series", "series", key, "parts", len(parts))
+		result.Summary, result.Error = c.reduceSeries(ctx, parts)
+		if result.Summary != "" {
+			result.SummaryFormat = c.summaryFormat(result.Depth)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// naturalLess orders strings with runs of digits compared by their value,
+// so that "part-2" comes before "part-10".
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			numA, restA := digitRun(a)
+			numB, restB := digitRun(b)
+			if len(numA) != len(numB) {
+				return len(numA) < len(numB)
+			}
+			if numA != numB {
+				return numA < numB
+			}
+			a, b = restA, restB
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+// digitRun splits s after its leading digits, returning them without
+// leading zeros.
+func digitRun(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return strings.TrimLeft(s[:i], "0"), s[i:]
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+func (c *Crawler) reduceSeries(ctx context.Context, parts []seriesPart) (string, error) {
+	if len(parts) == 1 {
+		return c.summarize(ctx, parts[0].content, parts[0].depth)
+	}
+
+	var partials strings.Builder
+	depth := parts[0].depth
+	for i, part := range parts {
+		depth = min(depth, part.depth)
+		summary, err := c.summarize(ctx, part.content, part.depth)
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize part %d (%s): %v", i+1, part.url, err)
+		}
+		fmt.Fprintf(&partials, "Part %d: %s\n%s\n\n", i+1, part.title, summary)
+	}
+
+	summary, err := c.summarize(ctx, partials.String(), depth)
+	if err != nil {
+		return "", fmt.Errorf("failed to merge series summaries: %v", err)
+	}
+	return summary, nil
+}
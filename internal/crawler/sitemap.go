@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// maxSitemapDepth caps how many levels of sitemap indexes are followed.
+	maxSitemapDepth = 3
+
+	// maxSitemapSize is the largest (uncompressed) sitemap read, the limit
+	// the sitemap protocol sets.
+	maxSitemapSize = 50 << 20
+)
+
+// sitemapDoc is either a <urlset> of pages or a <sitemapindex> of further
+// sitemaps.
+type sitemapDoc struct {
+	XMLName xml.Name
+	URLs    []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+// Sitemap returns the page URLs listed in the sitemap at sitemapURL,
+// following sitemap indexes up to three levels deep. Gzipped sitemaps are
+// decompressed. Sub-sitemaps that fail are logged and skipped; an error is
+// only returned when sitemapURL itself can't be read.
+func (c *Crawler) Sitemap(ctx context.Context, sitemapURL string) ([]string, error) {
+	seen := make(map[string]bool)
+	return c.readSitemap(ctx, sitemapURL, 0, seen)
+}
+
+func (c *Crawler) readSitemap(ctx context.Context, sitemapURL string, depth int, seen map[string]bool) ([]string, error) {
+	seen[sitemapURL] = true
+	doc, err := c.fetchSitemap(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(doc.URLs))
+	for _, entry := range doc.URLs {
+		if loc := strings.TrimSpace(entry.Loc); loc != "" {
+			urls = append(urls, loc)
+		}
+	}
+	for _, entry := range doc.Sitemaps {
+		loc := strings.TrimSpace(entry.Loc)
+		if loc == "" || seen[loc] {
+			continue
+		}
+		if depth+1 > maxSitemapDepth {
+			c.logger.Warn("Not following sitemap nested too deep", "url", loc, "max_depth", maxSitemapDepth)
+			continue
+		}
+		nested, err := c.readSitemap(ctx, loc, depth+1, seen)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			c.logger.Warn("Skipping sitemap", "url", loc, "error", err)
+			continue
+		}
+		urls = append(urls, nested...)
+	}
+	c.logger.Debug("Read sitemap", "url", sitemapURL, "urls", len(doc.URLs), "sitemaps", len(doc.Sitemaps))
+	return urls, nil
+}
+
+func (c *Crawler) fetchSitemap(ctx context.Context, sitemapURL string) (*sitemapDoc, error) {
+	u, err := url.Parse(sitemapURL)
+	if err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("invalid sitemap URL %q", sitemapURL)
+	}
+	if err := c.waitForHost(ctx, u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	c.prepareRequest(req)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sitemap: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sitemap %s returned status %d", sitemapURL, resp.StatusCode)
+	}
+
+	body, err := sitemapReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress sitemap %s: %v", sitemapURL, err)
+	}
+	var doc sitemapDoc
+	if err := xml.NewDecoder(io.LimitReader(body, maxSitemapSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap %s: %v", sitemapURL, err)
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("%s is not a sitemap (root element <%s>)", sitemapURL, doc.XMLName.Local)
+	}
+	return &doc, nil
+}
+
+// sitemapReader returns r, gunzipped when it starts with the gzip magic
+// number. Servers send .xml.gz files under all sorts of content types, so
+// the body itself is checked.
+func sitemapReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return buffered, nil
+	}
+	return gzip.NewReader(buffered)
+}
+
+// seedSitemap pushes the URLs of Config.SitemapURL as depth-0 seeds,
+// skipping those out of scope, filtered out or already discovered.
+func (c *Crawler) seedSitemap(ctx context.Context) {
+	urls, err := c.Sitemap(ctx, c.config.SitemapURL)
+	if err != nil {
+		c.logger.Warn("Not seeding from sitemap", "error", err)
+		return
+	}
+
+	added := 0
+	for _, loc := range urls {
+		u, err := url.Parse(loc)
+		if err != nil || !u.IsAbs() {
+			continue
+		}
+		link := c.canonicalLink(ctx, u)
+		if !c.inScope(u, 0) {
+			c.skip(SkipRecord{URL: link, Reason: SkipHost, ParentURL: c.config.SitemapURL})
+			continue
+		}
+		if ok, detail := c.matchURLPatterns(link); !ok {
+			c.skip(SkipRecord{URL: link, Reason: SkipPattern, ParentURL: c.config.SitemapURL, Detail: detail})
+			continue
+		}
+		if !c.markDiscovered(link) {
+			continue
+		}
+		c.pushFrontier(FrontierItem{URL: link, Depth: 0})
+		added++
+	}
+	c.logger.Debug("Seeded sitemap URLs", "added", added, "listed", len(urls))
+}
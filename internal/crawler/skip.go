@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"sort"
+)
+
+// SkipReason is a machine-readable code for why a URL was not crawled or
+// summarized.
+type SkipReason string
+
+const (
+	SkipDepth       SkipReason = "depth"        // beyond MaxDepth
+	SkipHost        SkipReason = "host"         // not an allowed host
+	SkipStatus      SkipReason = "status"       // status code not accepted
+	SkipContentType SkipReason = "content_type" // not HTML
+	SkipDuplicate   SkipReason = "duplicate"    // already discovered
+	SkipSelfLink    SkipReason = "self_link"    // link back to the same page
+	SkipUnfocused   SkipReason = "unfocused"    // found on a page below FocusThreshold
+	SkipLinkSource  SkipReason = "link_source"  // not where LinkSource follows links from
+	SkipRobots      SkipReason = "robots"       // disallowed by robots.txt
+	SkipPattern     SkipReason = "pattern"      // excluded by IncludePatterns or ExcludePatterns
+	SkipNoFollow    SkipReason = "nofollow"     // rel="nofollow" link or found on a nofollow page
+	SkipNoIndex     SkipReason = "noindex"      // page marked noindex by its robots meta tag
+	SkipLang        SkipReason = "lang"         // not in AllowedLangs; recorded but not summarized
+	SkipTooLarge    SkipReason = "too_large"    // body larger than MaxContentBytes
+)
+
+// SkipRecord describes one skipped URL.
+type SkipRecord struct {
+	URL    string
+	Reason SkipReason
+	Detail string
+	// ParentURL is the page the URL was found on, if any.
+	ParentURL string
+}
+
+// WithSkipHandler registers a function called for every skipped URL. It
+// may be called from several goroutines at once.
+func WithSkipHandler(handle func(SkipRecord)) Option {
+	return func(c *Crawler) {
+		c.skipHandlers = append(c.skipHandlers, handle)
+	}
+}
+
+// skip records that a URL was skipped: it is logged, counted in the stats
+// and passed to the skip handlers.
+func (c *Crawler) skip(record SkipRecord) {
+	c.logger.Debug("Skipped URL", "url", record.URL, "reason", record.Reason, "parent_url", record.ParentURL, "detail", record.Detail)
+	c.stats.addSkip(record.Reason)
+	for _, handle := range c.skipHandlers {
+		handle(record)
+	}
+}
+
+// ErrorCategories returns the categories in s.Errors in a stable order.
+func (s Stats) ErrorCategories() []string {
+	categories := make([]string, 0, len(s.Errors))
+	for category := range s.Errors {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// SkipReasons returns the reasons in s.Skipped in a stable order.
+func (s Stats) SkipReasons() []SkipReason {
+	reasons := make([]SkipReason, 0, len(s.Skipped))
+	for reason := range s.Skipped {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool { return reasons[i] < reasons[j] })
+	return reasons
+}
@@ -0,0 +1,56 @@
+package crawler
+
+// urlState tracks how far a URL has progressed through the crawl. URLs move
+// from discovered (known and enqueued, not yet requested) to fetched (a
+// response was received); a URL only counts as visited once fetched.
+type urlState int32
+
+const (
+	stateDiscovered urlState = iota + 1
+	stateFetched
+)
+
+// markDiscovered records urlStr as discovered and reports whether it was new,
+// i.e. whether the caller should enqueue it. URLs the store has seen in an
+// earlier run count as already fetched.
+func (c *Crawler) markDiscovered(urlStr string) bool {
+	_, loaded := c.visited.LoadOrStore(urlStr, stateDiscovered)
+	if loaded {
+		return false
+	}
+	if c.seenInStore(urlStr) {
+		c.visited.Store(urlStr, stateFetched)
+		return false
+	}
+	c.stats.discovered.Add(1)
+	return true
+}
+
+// seenInStore reports whether the store has urlStr from an earlier run.
+func (c *Crawler) seenInStore(urlStr string) bool {
+	if c.store == nil || c.storeReset.Load() {
+		return false
+	}
+	seen, err := c.store.Seen(urlStr)
+	if err != nil {
+		c.logger.Warn("Failed to look up URL in store", "url", urlStr, "error", err)
+		return false
+	}
+	if seen {
+		c.logger.Debug("Already crawled in an earlier run", "url", urlStr)
+	}
+	return seen
+}
+
+// markFetched moves urlStr to the fetched state. URLs fetched without being
+// discovered first (such as seeds added outside Crawl) are recorded too.
+func (c *Crawler) markFetched(urlStr string) {
+	if c.visited.CompareAndSwap(urlStr, stateDiscovered, stateFetched) {
+		c.stats.fetched.Add(1)
+		return
+	}
+	if _, loaded := c.visited.LoadOrStore(urlStr, stateFetched); !loaded {
+		c.stats.discovered.Add(1)
+		c.stats.fetched.Add(1)
+	}
+}
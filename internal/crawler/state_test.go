@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+)
+
+// seenStore is a Store that has seen the URLs in seen; a nil map makes
+// every lookup fail.
+type seenStore struct {
+	seen map[string]bool
+}
+
+func (s seenStore) Save(Result) error { return nil }
+
+func (s seenStore) Seen(url string) (bool, error) {
+	if s.seen == nil {
+		return false, errors.New("store unavailable")
+	}
+	return s.seen[url], nil
+}
+
+func urlStateOf(c *Crawler, urlStr string) urlState {
+	state, ok := c.visited.Load(urlStr)
+	if !ok {
+		return 0
+	}
+	return state.(urlState)
+}
+
+func TestURLStateTransitions(t *testing.T) {
+	const page = "http://example.com/page"
+	c := &Crawler{config: &Config{}, logger: slog.Default()}
+
+	if !c.markDiscovered(page) {
+		t.Fatal("first markDiscovered = false, want true")
+	}
+	if got := urlStateOf(c, page); got != stateDiscovered {
+		t.Fatalf("state after discovery = %d, want discovered", got)
+	}
+	if c.markDiscovered(page) {
+		t.Error("second markDiscovered = true, want false")
+	}
+
+	c.markFetched(page)
+	if got := urlStateOf(c, page); got != stateFetched {
+		t.Fatalf("state after fetch = %d, want fetched", got)
+	}
+	if c.markDiscovered(page) {
+		t.Error("markDiscovered after fetch = true, want false")
+	}
+	c.markFetched(page)
+	if got := urlStateOf(c, page); got != stateFetched {
+		t.Errorf("state after a second fetch = %d, want fetched", got)
+	}
+
+	if discovered, fetched := c.stats.discovered.Load(), c.stats.fetched.Load(); discovered != 1 || fetched != 1 {
+		t.Errorf("discovered = %d, fetched = %d; want 1 each", discovered, fetched)
+	}
+}
+
+func TestMarkFetchedUndiscovered(t *testing.T) {
+	const seed = "http://example.com/seed"
+	c := &Crawler{config: &Config{}, logger: slog.Default()}
+
+	c.markFetched(seed)
+	if got := urlStateOf(c, seed); got != stateFetched {
+		t.Fatalf("state = %d, want fetched", got)
+	}
+	if c.markDiscovered(seed) {
+		t.Error("markDiscovered of a fetched seed = true, want false")
+	}
+	if discovered, fetched := c.stats.discovered.Load(), c.stats.fetched.Load(); discovered != 1 || fetched != 1 {
+		t.Errorf("discovered = %d, fetched = %d; want 1 each", discovered, fetched)
+	}
+}
+
+func TestMarkDiscoveredStore(t *testing.T) {
+	const (
+		old   = "http://example.com/old"
+		fresh = "http://example.com/new"
+	)
+	c := &Crawler{config: &Config{}, logger: slog.Default(), store: seenStore{seen: map[string]bool{old: true}}}
+
+	if c.markDiscovered(old) {
+		t.Error("markDiscovered of a URL from an earlier run = true, want false")
+	}
+	if got := urlStateOf(c, old); got != stateFetched {
+		t.Errorf("state of a URL from an earlier run = %d, want fetched", got)
+	}
+	if !c.markDiscovered(fresh) {
+		t.Error("markDiscovered of a new URL = false, want true")
+	}
+	if discovered := c.stats.discovered.Load(); discovered != 1 {
+		t.Errorf("discovered = %d, want only the new URL counted", discovered)
+	}
+
+	// Once the store is reset, earlier runs no longer count.
+	c.storeReset.Store(true)
+	const other = "http://example.com/other"
+	c.store = seenStore{seen: map[string]bool{other: true}}
+	if !c.markDiscovered(other) {
+		t.Error("markDiscovered after a store reset = false, want true")
+	}
+
+	// A failing lookup is logged and the URL crawled.
+	c.storeReset.Store(false)
+	c.store = seenStore{}
+	if !c.markDiscovered("http://example.com/unknown") {
+		t.Error("markDiscovered with a failing store = false, want true")
+	}
+}
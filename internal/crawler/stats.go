@@ -0,0 +1,205 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"webcrawler/internal/parser"
+)
+
+// Stats is a snapshot of crawl-wide counters.
+type Stats struct {
+	// Discovered counts URLs found and enqueued; Fetched counts those
+	// actually requested and answered.
+	Discovered int64
+	Fetched    int64
+
+	// Succeeded and Failed count the pages crawled with and without an
+	// error; Errors breaks Failed down by category, such as "status",
+	// "timeout" or "network".
+	Succeeded int64
+	Failed    int64
+	Errors    map[string]int64
+
+	// BytesDownloaded is the total size of the page bodies received.
+	BytesDownloaded int64
+
+	// Summaries counts summaries generated, SummaryErrors the attempts
+	// that failed. Summaries reused from the content hash file are not
+	// counted.
+	Summaries     int64
+	SummaryErrors int64
+
+	// Elapsed is the time since the first crawl started, up to the end of
+	// the last one when none is running; PagesPerSecond is Fetched over
+	// Elapsed.
+	Elapsed        time.Duration
+	PagesPerSecond float64
+
+	InternalLinks int64
+	ExternalLinks int64
+
+	// Filtered counts results dropped by result filters.
+	Filtered int64
+
+	// BrokenLinks counts entries written to the broken links report.
+	BrokenLinks int64
+
+	// Redactions counts text removed by RedactPatterns.
+	Redactions int64
+
+	// BodyFallbacks counts pages extracted from the whole body because no
+	// content container matched.
+	BodyFallbacks int64
+
+	// Skipped counts skipped URLs by reason.
+	Skipped map[SkipReason]int64
+}
+
+// crawlStats holds the live counters behind Stats.
+type crawlStats struct {
+	discovered    atomic.Int64
+	fetched       atomic.Int64
+	succeeded     atomic.Int64
+	bytes         atomic.Int64
+	summaries     atomic.Int64
+	summaryErrors atomic.Int64
+	internalLinks atomic.Int64
+	externalLinks atomic.Int64
+	filtered      atomic.Int64
+	brokenLinks   atomic.Int64
+	redactions    atomic.Int64
+	bodyFallbacks atomic.Int64
+
+	// countsMu guards the per-reason and per-category counts.
+	countsMu sync.Mutex
+	skipped  map[SkipReason]int64
+	errors   map[string]int64
+
+	// started and finished are Unix nanoseconds; finished is zero while a
+	// crawl is running.
+	started  atomic.Int64
+	finished atomic.Int64
+}
+
+func (s *crawlStats) addSkip(reason SkipReason) {
+	s.countsMu.Lock()
+	defer s.countsMu.Unlock()
+	if s.skipped == nil {
+		s.skipped = make(map[SkipReason]int64)
+	}
+	s.skipped[reason]++
+}
+
+// addResult counts a crawled page as succeeded or, under its error's
+// category, failed. Pages skipped without an error count as neither.
+func (s *crawlStats) addResult(result Result) {
+	if result.Error == nil {
+		if result.Skipped == "" {
+			s.succeeded.Add(1)
+		}
+		return
+	}
+	s.countsMu.Lock()
+	defer s.countsMu.Unlock()
+	if s.errors == nil {
+		s.errors = make(map[string]int64)
+	}
+	s.errors[errorCategory(result)]++
+}
+
+// errorCategory names the kind of failure behind result's error: the skip
+// reason when the response was rejected, else a category guessed from
+// the error.
+func errorCategory(result Result) string {
+	if result.Skipped != "" {
+		return string(result.Skipped)
+	}
+	msg := result.Error.Error()
+	switch {
+	case errors.Is(result.Error, context.Canceled):
+		return "canceled"
+	case errors.Is(result.Error, parser.ErrSelectorNotFound):
+		return "selector_not_found"
+	case errors.Is(result.Error, context.DeadlineExceeded) || strings.Contains(strings.ToLower(msg), "timeout"):
+		return "timeout"
+	case strings.HasPrefix(msg, "invalid URL"):
+		return "invalid_url"
+	case strings.Contains(msg, "redirects"):
+		return "redirects"
+	case strings.Contains(msg, "failed to navigate") || strings.Contains(msg, "failed to fetch"):
+		return "network"
+	}
+	return "parse"
+}
+
+// start records the start of a crawl; the first one starts the clock.
+func (s *crawlStats) start() {
+	s.started.CompareAndSwap(0, time.Now().UnixNano())
+	s.finished.Store(0)
+}
+
+// finish records the end of a crawl.
+func (s *crawlStats) finish() {
+	s.finished.Store(time.Now().UnixNano())
+}
+
+// elapsed returns the time counted towards Stats.Elapsed.
+func (s *crawlStats) elapsed() time.Duration {
+	started := s.started.Load()
+	if started == 0 {
+		return 0
+	}
+	end := s.finished.Load()
+	if end == 0 {
+		end = time.Now().UnixNano()
+	}
+	return time.Duration(end - started)
+}
+
+// Stats returns the current counters. It is safe to call while crawling.
+func (c *Crawler) Stats() Stats {
+	c.stats.countsMu.Lock()
+	skipped := make(map[SkipReason]int64, len(c.stats.skipped))
+	for reason, count := range c.stats.skipped {
+		skipped[reason] = count
+	}
+	errs := make(map[string]int64, len(c.stats.errors))
+	var failed int64
+	for category, count := range c.stats.errors {
+		errs[category] = count
+		failed += count
+	}
+	c.stats.countsMu.Unlock()
+
+	fetched := c.stats.fetched.Load()
+	elapsed := c.stats.elapsed()
+	var pagesPerSecond float64
+	if elapsed > 0 {
+		pagesPerSecond = float64(fetched) / elapsed.Seconds()
+	}
+
+	return Stats{
+		Discovered:      c.stats.discovered.Load(),
+		Fetched:         fetched,
+		Succeeded:       c.stats.succeeded.Load(),
+		Failed:          failed,
+		Errors:          errs,
+		BytesDownloaded: c.stats.bytes.Load(),
+		Summaries:       c.stats.summaries.Load(),
+		SummaryErrors:   c.stats.summaryErrors.Load(),
+		Elapsed:         elapsed,
+		PagesPerSecond:  pagesPerSecond,
+		InternalLinks:   c.stats.internalLinks.Load(),
+		ExternalLinks:   c.stats.externalLinks.Load(),
+		Filtered:        c.stats.filtered.Load(),
+		BrokenLinks:     c.stats.brokenLinks.Load(),
+		Redactions:      c.stats.redactions.Load(),
+		BodyFallbacks:   c.stats.bodyFallbacks.Load(),
+		Skipped:         skipped,
+	}
+}
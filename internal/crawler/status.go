@@ -0,0 +1,36 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+var statusRangePattern = regexp.MustCompile(`^[1-5][xX]{2}$`)
+
+// compileStatusRanges validates ranges such as "2xx" and returns the status
+// classes (hundreds digit) they cover.
+func compileStatusRanges(ranges []string) (map[int]bool, error) {
+	classes := make(map[int]bool, len(ranges))
+	for _, r := range ranges {
+		if !statusRangePattern.MatchString(r) {
+			return nil, fmt.Errorf("invalid status range %q, expected e.g. \"2xx\"", r)
+		}
+		classes[int(r[0]-'0')] = true
+	}
+	return classes, nil
+}
+
+// acceptStatus reports whether a page with the given status should be
+// processed. Without any configured codes or ranges only 200 is accepted.
+func (c *Crawler) acceptStatus(code int) bool {
+	if len(c.config.AcceptStatusCodes) == 0 && len(c.statusClasses) == 0 {
+		return code == http.StatusOK
+	}
+	for _, accepted := range c.config.AcceptStatusCodes {
+		if code == accepted {
+			return true
+		}
+	}
+	return c.statusClasses[code/100]
+}
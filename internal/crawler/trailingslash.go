@@ -0,0 +1,134 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+const (
+	TrailingSlashStrip    = "strip"
+	TrailingSlashPreserve = "preserve"
+	TrailingSlashAuto     = "auto"
+)
+
+// slashProbe is the cached outcome of probing a host in auto mode.
+type slashProbe struct {
+	once sync.Once
+	// strip is set when the host redirects between /path and /path/, so
+	// the two name the same page.
+	strip bool
+}
+
+func validateTrailingSlash(mode string) error {
+	switch mode {
+	case "", TrailingSlashStrip, TrailingSlashPreserve, TrailingSlashAuto:
+		return nil
+	default:
+		return fmt.Errorf("unknown trailing slash mode %q (want strip, preserve or auto)", mode)
+	}
+}
+
+// trailingSlashMode returns the mode for u's host: its profile's, else the
+// crawler-wide one, else strip.
+func (c *Crawler) trailingSlashMode(u *url.URL) string {
+	if profile, ok := c.hostProfile(u); ok && profile.TrailingSlash != "" {
+		return profile.TrailingSlash
+	}
+	if c.config.TrailingSlash != "" {
+		return c.config.TrailingSlash
+	}
+	return TrailingSlashStrip
+}
+
+// canonicalLink returns the form of u the crawler tracks and fetches: u
+// normalized by normalizeURL, with a trailing slash dropped or kept
+// according to the host's mode. Hash routes under HashRouting keep their
+// fragment.
+func (c *Crawler) canonicalLink(ctx context.Context, u *url.URL) string {
+	page := normalizeURL(u, c.config.StripTrackingParams)
+	link := page.String()
+
+	var route string
+	if c.config.HashRouting && isHashRoute(u.Fragment) {
+		route = "#" + u.EscapedFragment()
+		if page.Path == "" || page.Path == "/" {
+			// Keep the app's root as https://host/#/route.
+			page.Path = "/"
+			return page.String() + route
+		}
+	}
+
+	switch c.trailingSlashMode(u) {
+	case TrailingSlashPreserve:
+		return link + route
+	case TrailingSlashAuto:
+		if !c.probeTrailingSlash(ctx, u) {
+			return link + route
+		}
+	}
+	return strings.TrimRight(link, "/") + route
+}
+
+// probeTrailingSlash reports whether u's host treats /path and /path/ as
+// one page. The first URL with a path seen for a host is probed: if
+// requesting its other form redirects back to it, the host is taken to
+// redirect between the two everywhere. Hosts that answer both forms
+// directly keep them distinct.
+func (c *Crawler) probeTrailingSlash(ctx context.Context, u *url.URL) bool {
+	if u.Path == "" || u.Path == "/" {
+		// Nothing to learn from the root; it keeps its slash-less form.
+		return true
+	}
+
+	value, _ := c.slashProbes.LoadOrStore(strings.ToLower(u.Host), &slashProbe{})
+	probe := value.(*slashProbe)
+	probe.once.Do(func() {
+		probe.strip = c.redirectsBetweenSlashForms(ctx, u)
+		c.logger.Debug("Probed trailing slash", "host", u.Host, "same_page", probe.strip)
+	})
+	return probe.strip
+}
+
+func (c *Crawler) redirectsBetweenSlashForms(ctx context.Context, u *url.URL) bool {
+	other := *u
+	other.RawQuery, other.Fragment = "", ""
+	if strings.HasSuffix(other.Path, "/") {
+		other.Path = strings.TrimRight(other.Path, "/")
+	} else {
+		other.Path += "/"
+	}
+	other.RawPath = ""
+
+	if err := c.waitForHost(ctx, &other); err != nil {
+		return false
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, other.String(), nil)
+	if err != nil {
+		return false
+	}
+	c.prepareRequest(req)
+
+	client := *c.httpClient
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		c.logger.Warn("Trailing slash probe failed", "url", other.String(), "error", err)
+		return false
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return false
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return false
+	}
+	return strings.TrimRight(location.Path, "/") == strings.TrimRight(u.Path, "/")
+}
@@ -0,0 +1,38 @@
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+)
+
+func compileURLPatterns(kind string, patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %v", kind, pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// matchURLPatterns reports whether link passes IncludePatterns and
+// ExcludePatterns, and otherwise which pattern rejected it. A link matching
+// an exclude pattern is rejected even if it is included.
+func (c *Crawler) matchURLPatterns(link string) (bool, string) {
+	for _, re := range c.excludePatterns {
+		if re.MatchString(link) {
+			return false, "excluded by " + re.String()
+		}
+	}
+	if len(c.includePatterns) == 0 {
+		return true, ""
+	}
+	for _, re := range c.includePatterns {
+		if re.MatchString(link) {
+			return true, ""
+		}
+	}
+	return false, "matches no include pattern"
+}
@@ -0,0 +1,87 @@
+package crawler
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMatchURLPatterns(t *testing.T) {
+	c, err := New(&Config{
+		SkipSummary:     true,
+		IncludePatterns: []string{"/docs/"},
+		ExcludePatterns: []string{`(?i)\.pdf$`, "/login"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"https://example.com/docs/intro":        true,
+		"https://example.com/docs/":             true,
+		"https://example.com/docs/manual.pdf":   false,
+		"https://example.com/docs/manual.PDF":   false,
+		"https://example.com/docs/login/help":   false,
+		"https://example.com/blog/post":         false,
+		"https://example.com/files/a-guide.pdf": false,
+	}
+	for link, want := range tests {
+		if got, reason := c.matchURLPatterns(link); got != want {
+			t.Errorf("matchURLPatterns(%q) = %v (%s), want %v", link, got, reason, want)
+		}
+	}
+}
+
+func TestNewRejectsInvalidURLPatterns(t *testing.T) {
+	for _, config := range []Config{
+		{IncludePatterns: []string{"/docs/("}},
+		{ExcludePatterns: []string{`\.pdf$`, "[a-"}},
+	} {
+		config.SkipSummary = true
+		if _, err := New(&config, nil); err == nil || !strings.Contains(err.Error(), "pattern") {
+			t.Errorf("New(%+v) = %v, want an invalid pattern error", config, err)
+		}
+	}
+}
+
+func TestCrawlURLPatterns(t *testing.T) {
+	fetcher := &graphFetcher{links: map[string][]string{
+		"/":           {"/docs/intro", "/docs/guide.pdf", "/login", "/blog"},
+		"/docs/intro": {"/docs/setup"},
+	}}
+	var skipped []string
+	c, err := New(&Config{
+		MaxDepth:         3,
+		MaxWorkers:       1,
+		SkipSummary:      true,
+		IgnoreRobots:     true,
+		IgnoreCrawlDelay: true,
+		AllowedHosts:     []string{"example.com"},
+		IncludePatterns:  []string{"/docs/"},
+		ExcludePatterns:  []string{`\.pdf$`, "/login"},
+	}, nil, WithFetcher(fetcher), WithSkipHandler(func(record SkipRecord) {
+		if record.Reason == SkipPattern {
+			skipped = append(skipped, strings.TrimPrefix(record.URL, "http://example.com"))
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher.c = c
+
+	results, err := c.Crawl(context.Background(), "http://example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range results {
+	}
+
+	// The seed is crawled though it matches no include pattern.
+	if want := []string{"/", "/docs/intro", "/docs/setup"}; !slices.Equal(fetcher.visited, want) {
+		t.Errorf("visited %v, want %v", fetcher.visited, want)
+	}
+	slices.Sort(skipped)
+	if want := []string{"/blog", "/docs/guide.pdf", "/login"}; !slices.Equal(skipped, want) {
+		t.Errorf("skipped by pattern %v, want %v", skipped, want)
+	}
+}
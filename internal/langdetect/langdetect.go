@@ -0,0 +1,110 @@
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords holds a handful of very common words per language. Counting them
+// is crude but good enough to tell apart paragraphs of Latin-script text.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "was", "on", "are", "this", "be", "as", "you", "not"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "zu", "den", "mit", "sich", "auf", "für", "von", "auch", "dem", "wir"},
+	"fr": {"le", "la", "les", "et", "des", "est", "un", "une", "du", "que", "pour", "dans", "pas", "sur", "qui", "avec", "sont", "nous"},
+	"es": {"el", "la", "los", "las", "y", "de", "que", "en", "un", "una", "es", "por", "con", "para", "del", "se", "no", "como"},
+	"it": {"il", "la", "di", "che", "e", "un", "una", "per", "non", "sono", "con", "del", "della", "gli", "le", "nel", "anche", "questo"},
+	"pt": {"o", "a", "os", "as", "de", "que", "e", "um", "uma", "para", "com", "não", "do", "da", "em", "por", "mais", "são"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "zijn", "voor", "met", "ook", "als", "maar", "wij", "naar"},
+}
+
+// scripts maps non-Latin scripts that identify a language (or a close enough
+// family) on their own.
+var scripts = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// Detect returns the ISO 639-1 code of the most likely language of text, or
+// an empty string when there is too little signal to tell.
+func Detect(text string) string {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, script := range scripts {
+			if unicode.Is(script.table, r) {
+				counts[script.lang]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	// Japanese text mixes kana with Han characters, so any kana wins over
+	// Chinese.
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+	if lang, n := best(counts); n*2 >= letters {
+		return lang
+	}
+
+	return detectLatin(text)
+}
+
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) < 3 {
+		return ""
+	}
+
+	index := make(map[string]int, len(words))
+	for _, word := range words {
+		index[word]++
+	}
+
+	scores := make(map[string]int)
+	for lang, list := range stopwords {
+		for _, word := range list {
+			scores[lang] += index[word]
+		}
+	}
+
+	lang, n := best(scores)
+	if n == 0 {
+		return ""
+	}
+	return lang
+}
+
+// best returns the key with the highest count, breaking ties
+// alphabetically so results are deterministic.
+func best(counts map[string]int) (string, int) {
+	var lang string
+	var max int
+	for l, n := range counts {
+		if n > max || (n == max && n > 0 && l < lang) {
+			lang, max = l, n
+		}
+	}
+	return lang, max
+}
@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// DecodeHTML transcodes a raw HTML body to UTF-8. The encoding is taken from
+// override when set, otherwise detected from a BOM, the Content-Type header
+// and <meta charset> tags, falling back to windows-1252 like browsers do.
+// The name of the encoding used is returned alongside the text.
+func DecodeHTML(body []byte, contentType, override string) (string, string, error) {
+	if override != "" {
+		enc, err := htmlindex.Get(override)
+		if err != nil {
+			return "", "", fmt.Errorf("unknown charset %q: %v", override, err)
+		}
+		name, _ := htmlindex.Name(enc)
+		decoded, err := enc.NewDecoder().Bytes(body)
+		if err != nil {
+			return "", "", fmt.Errorf("error decoding %s content: %v", name, err)
+		}
+		return string(decoded), name, nil
+	}
+
+	enc, name, certain := charset.DetermineEncoding(body, contentType)
+	// Undeclared pages that are already valid UTF-8 are left alone rather
+	// than being forced through the windows-1252 fallback.
+	if !certain && utf8.Valid(body) {
+		return string(body), "utf-8", nil
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return "", "", fmt.Errorf("error decoding %s content: %v", name, err)
+	}
+	return string(decoded), name, nil
+}
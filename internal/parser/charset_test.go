@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestDecodeHTML(t *testing.T) {
+	const text = "日本語のページです"
+	shiftJIS := func(page string) []byte {
+		encoded, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encoded
+	}
+	plain := "<html><body><p>" + text + "</p></body></html>"
+	withMeta := `<html><head><meta charset="Shift_JIS"></head><body><p>` + text + "</p></body></html>"
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		override    string
+		wantName    string
+	}{
+		{name: "content type header", body: shiftJIS(plain), contentType: "text/html; charset=Shift_JIS", wantName: "shift_jis"},
+		{name: "meta charset", body: shiftJIS(withMeta), contentType: "text/html", wantName: "shift_jis"},
+		{name: "override beats header", body: shiftJIS(plain), contentType: "text/html; charset=utf-8", override: "sjis", wantName: "shift_jis"},
+		{name: "undeclared utf-8", body: []byte(plain), contentType: "text/html", wantName: "utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, name, err := DecodeHTML(tt.body, tt.contentType, tt.override)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != tt.wantName {
+				t.Errorf("charset = %q, want %q", name, tt.wantName)
+			}
+			if !strings.Contains(decoded, text) {
+				t.Errorf("decoded = %q, want it to contain %q", decoded, text)
+			}
+		})
+	}
+
+	if _, _, err := DecodeHTML([]byte(plain), "text/html", "no-such-charset"); err == nil {
+		t.Error("unknown override charset accepted")
+	}
+}
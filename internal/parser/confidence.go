@@ -0,0 +1,48 @@
+package parser
+
+import "strings"
+
+const (
+	// confidentWords is the amount of extracted text above which length no
+	// longer adds to the confidence score.
+	confidentWords = 300
+	// confidentWordsPerLink is the text-to-link ratio typical of articles;
+	// navigation-heavy pages fall well below it.
+	confidentWordsPerLink = 20
+	// fallbackPenalty scales down text recovered with fallback selectors.
+	fallbackPenalty = 0.6
+	// bodyPenalty scales down text taken from the whole body, which mixes
+	// in menus, footers and other chrome.
+	bodyPenalty = 0.7
+)
+
+// extractionConfidence estimates, between 0 and 1, how likely text is the
+// page's real main content rather than an empty shell or boilerplate.
+func extractionConfidence(text string, linkCount int, usedFallback, usedBody bool) float64 {
+	words := len(strings.Fields(text))
+	if words == 0 {
+		return 0
+	}
+
+	lengthScore := clamp(float64(words) / confidentWords)
+	densityScore := clamp(float64(words) / float64(linkCount+1) / confidentWordsPerLink)
+
+	confidence := 0.7*lengthScore + 0.3*densityScore
+	if usedFallback {
+		confidence *= fallbackPenalty
+	}
+	if usedBody {
+		confidence *= bodyPenalty
+	}
+	return confidence
+}
+
+func clamp(v float64) float64 {
+	if v > 1 {
+		return 1
+	}
+	if v < 0 {
+		return 0
+	}
+	return v
+}
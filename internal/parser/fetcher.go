@@ -0,0 +1,21 @@
+package parser
+
+import "context"
+
+// Fetcher loads a page and extracts its content and links.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string, opts ParseOptions) (ParseResult, error)
+}
+
+// BrowserFetcher renders pages in the shared headless browser, running
+// their scripts, with ParseWithPlaywright.
+type BrowserFetcher struct{}
+
+// Fetch parses url with ParseWithPlaywright. The browser cannot be
+// interrupted mid-page, so ctx is only checked before it starts.
+func (BrowserFetcher) Fetch(ctx context.Context, url string, opts ParseOptions) (ParseResult, error) {
+	if err := ctx.Err(); err != nil {
+		return ParseResult{}, err
+	}
+	return ParseWithPlaywright(url, opts)
+}
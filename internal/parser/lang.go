@@ -0,0 +1,29 @@
+package parser
+
+import (
+	"strings"
+
+	"webcrawler/internal/langdetect"
+)
+
+// NormalizeLang reduces a language tag such as "en-US" or "pt_BR" to its
+// lowercase primary subtag ("en", "pt"), or "" when tag is empty.
+func NormalizeLang(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(tag)
+}
+
+// pageLang returns the page's language: declared, the <html lang> value,
+// or when that is missing and detect is set, the language of text.
+func pageLang(declared, text string, detect bool) string {
+	if lang := NormalizeLang(declared); lang != "" {
+		return lang
+	}
+	if detect {
+		return langdetect.Detect(text)
+	}
+	return ""
+}
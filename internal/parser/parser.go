@@ -1,260 +1,1501 @@
 package parser
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/playwright-community/playwright-go"
+	"golang.org/x/net/html"
 )
 
+// DefaultUserAgent is the user agent pages are requested with unless
+// ParseOptions.UserAgent or the emulated device overrides it.
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+
+// The headers pages are requested with unless ParseOptions overrides them.
+const (
+	acceptHeader         = "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8"
+	acceptLanguageHeader = "en-US,en;q=0.5"
+)
+
+// DefaultBlockedResourceTypes are the resource types that content
+// extraction never needs.
+var DefaultBlockedResourceTypes = []string{"image", "font", "media", "stylesheet"}
+
+// waitForSelectorTimeout bounds the wait for ParseOptions.WaitForSelector.
+const waitForSelectorTimeout = 15 * time.Second
+
+// ErrSelectorNotFound is returned, wrapped, when the element named by
+// ParseOptions.WaitForSelector does not appear in time.
+var ErrSelectorNotFound = errors.New("selector did not appear")
+
+// ErrContentTooLarge is returned, wrapped, when a page's body is larger
+// than ParseOptions.MaxContentBytes.
+var ErrContentTooLarge = errors.New("content too large")
+
+// ErrDownload is returned, wrapped, when the browser downloads the page
+// instead of rendering it, as it does for PDFs, archives and other files.
+// The navigation then has no response to pass to ParseOptions.CheckResponse.
+var ErrDownload = errors.New("page is a download")
+
+// ErrNavigationTimeout is returned, wrapped, when the browser's navigation
+// to a page does not finish in time.
+var ErrNavigationTimeout = errors.New("navigation timed out")
+
+// downloadErrors are parts of the messages Chromium, Firefox and WebKit
+// fail a navigation with when it turns into a download.
+var downloadErrors = []string{"Download is starting", "net::ERR_ABORTED", "NS_BINDING_ABORTED", "Frame load interrupted"}
+
+// isDownload reports whether err is a navigation aborted by a download.
+func isDownload(err error) bool {
+	for _, marker := range downloadErrors {
+		if strings.Contains(err.Error(), marker) {
+			return true
+		}
+	}
+	return false
+}
+
 type ParseResult struct {
+	Title string
 	Text  string
-	Links []string
+	Links []Link
+
+	// Response describes the HTTP response the page was loaded from.
+	Response Response
+
+	// Confidence estimates, from 0 to 1, how likely Text is the page's main
+	// content.
+	Confidence float64
+
+	// Comments is the text of the page's comment sections, extracted when
+	// IncludeComments is set.
+	Comments string
+
+	// MatchedSelector is the selector of the element the text was
+	// extracted from; BodySelector means no content container was found.
+	MatchedSelector string
+
+	// AMPURL is the AMP version the content was extracted from when
+	// PreferAMP found one.
+	AMPURL string
+
+	// Published is the publication date the page declares in its
+	// metadata, as written there (usually ISO 8601), or empty.
+	Published string
+
+	// Description is the page's <meta name="description"> (or
+	// og:description) and Canonical the absolute URL of its
+	// <link rel="canonical">; either is empty when the page has none.
+	Description string
+	Canonical   string
+
+	// RobotsMeta holds the page's <meta name="robots"> directives.
+	RobotsMeta RobotsMeta
+
+	// ScreenshotPath is the full-page PNG saved with
+	// ParseOptions.Screenshot, empty when none was taken.
+	ScreenshotPath string
+
+	// Lang is the page's language as a lowercase primary subtag ("en"),
+	// from its <html lang> or, with ParseOptions.DetectLang, guessed from
+	// its text; empty when unknown.
+	Lang string
+}
+
+// Response describes the response to a page's navigation.
+type Response struct {
+	// StatusCode is 0 when the browser reported no response, e.g. for a
+	// navigation within the same document.
+	StatusCode  int
+	Status      string
+	ContentType string
+
+	// URL is the final URL, reached after Redirects redirects.
+	URL       string
+	Redirects int
+
+	// RetryAfter and LastModified are the Retry-After and Last-Modified
+	// headers, if any, as sent.
+	RetryAfter   string
+	LastModified string
+
+	// BodySize is the length of the response body in bytes, or 0 when it
+	// is unknown.
+	BodySize int64
+
+	// ContentLength is the Content-Length the server declared, or -1 (0
+	// without a response) when it declared none.
+	ContentLength int64
+}
+
+// ParseOptions controls how a page is extracted.
+type ParseOptions struct {
+	// PreserveStructure keeps headings as Markdown headings and list items
+	// as bullets instead of collapsing the page into flat prose.
+	PreserveStructure bool
+
+	// UserAgent, Headers and Cookies override the browser defaults for the
+	// page, typically taken from the host's profile.
+	UserAgent string
+	Headers   map[string]string
+	Cookies   map[string]string
+
+	// ContentSelectors are tried in order to find the page's main content
+	// (a built-in list ending at body when nil). RemoveSelectors match the
+	// elements stripped from it before its text is taken, such as
+	// navigation and share buttons (a built-in list when nil).
+	ContentSelectors []string
+	RemoveSelectors  []string
+
+	// MinContentLength is the shortest extraction accepted before retrying
+	// with FallbackSelectors (a built-in chain ending at body when nil).
+	MinContentLength  int
+	FallbackSelectors []string
+
+	// Charset forces the encoding used to decode fetched HTML (e.g.
+	// "shift_jis") instead of detecting it from the response.
+	Charset string
+
+	// PreferAMP extracts from the page's <link rel="amphtml"> version when
+	// it declares one.
+	PreferAMP bool
+
+	// IncludeComments keeps comment sections in the extracted text instead
+	// of stripping them, and also returns them on their own in
+	// ParseResult.Comments.
+	IncludeComments bool
+
+	// Device emulates one of Playwright's named devices (e.g. "iPhone 13"),
+	// setting its viewport, scale factor, touch and user agent. Viewport
+	// and DeviceScaleFactor, when set, override the device's values.
+	Device            string
+	Viewport          Viewport
+	DeviceScaleFactor float64
+
+	// HashRouting waits, after loading a URL whose fragment is a hash
+	// route (#/path or #!/path), for the app to render that route.
+	HashRouting bool
+
+	// WaitForSelector waits, after the page loads, for an element matching
+	// this CSS selector to become visible before extracting, for content
+	// that client-side code renders after the network goes idle. The parse
+	// fails with ErrSelectorNotFound if it does not appear within 15s.
+	// WaitForTimeout is then waited on top. The static fetcher ignores
+	// both.
+	WaitForSelector string
+	WaitForTimeout  time.Duration
+
+	// BlockResourceTypes aborts the browser's requests for these Playwright
+	// resource types (such as DefaultBlockedResourceTypes), which speeds up
+	// rendering. Leave it empty for sites whose content depends on CSS,
+	// since without stylesheets elements hidden with display:none are
+	// extracted too.
+	BlockResourceTypes []string
+
+	// Screenshot saves a full-page PNG of each page to ScreenshotDir
+	// (default "screenshots"), named by a hash of the URL. A failed
+	// capture is logged and doesn't fail the parse. The static fetcher
+	// can't take screenshots.
+	Screenshot    bool
+	ScreenshotDir string
+
+	// MaxContentBytes rejects pages whose body is larger, with
+	// ErrContentTooLarge, instead of extracting them. The static fetcher
+	// stops reading at the limit; the browser has the whole page by then
+	// and can only refuse to extract it. Zero means no limit beyond the
+	// static fetcher's own 10 MiB, at which it truncates.
+	MaxContentBytes int64
+
+	// ExtractMode chooses how the main content is found: ExtractSelectors
+	// (the default) takes the first element matching ContentSelectors,
+	// while ExtractReadability scores the page's blocks by their text and
+	// link density, as Mozilla's Readability does, which works on sites
+	// without the usual article or main containers. When readability
+	// finds no paragraphs, the selectors are used after all.
+	ExtractMode string
+
+	// Browser is the engine pages are rendered with: BrowserChromium (the
+	// default), BrowserFirefox or BrowserWebKit. Each is launched the first
+	// time a page asks for it, with its own context pool, and must have
+	// been installed with the Playwright CLI. Only Chromium can be reached
+	// through BrowserOptions.WSEndpoint.
+	Browser string
+
+	// DetectLang guesses ParseResult.Lang from the extracted text when the
+	// page doesn't declare its language.
+	DetectLang bool
+
+	// NoscriptFallback uses the text of the page's <noscript> elements when
+	// the rendered extraction comes back empty or shorter than
+	// MinContentLength, for progressively enhanced sites that only put
+	// their content there.
+	NoscriptFallback bool
+
+	// Transport, when set, carries every request the browser makes instead
+	// of the browser's own network stack, e.g. to record or replay them.
+	// With TransportDocumentsOnly it only carries the page's own document
+	// requests (including redirects), not its subresources.
+	Transport              http.RoundTripper
+	TransportDocumentsOnly bool
+
+	// CheckResponse, when set, is called with the page's response before
+	// anything is extracted. An error stops the parse and is returned
+	// unchanged.
+	CheckResponse func(Response) error
+
+	// ExtractScript replaces the built-in extraction entirely. It is a
+	// JavaScript function evaluated in the page that must return
+	// {text: string, links: string[]}; the selectors, fallbacks and
+	// comment handling above are then not used.
+	ExtractScript string
+}
+
+// Viewport is a browser window size in CSS pixels. The zero value keeps
+// the default.
+type Viewport struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// BrowserOptions controls how the shared browser is obtained.
+type BrowserOptions struct {
+	// WSEndpoint attaches to a running Chromium over CDP (a ws:// or
+	// http:// endpoint, e.g. from --remote-debugging-port) instead of
+	// launching one. Cleanup then only disconnects from it.
+	WSEndpoint string
+
+	// PoolSize is the number of browser contexts reused across pages, and
+	// so the number of pages open at once (default 4), in each browser
+	// engine. A context is replaced once it is older than ContextMaxAge
+	// or has served ContextMaxUses pages; zero means no limit.
+	PoolSize       int
+	ContextMaxAge  time.Duration
+	ContextMaxUses int
+
+	// Proxy, if set, is the http, https or socks5 proxy the browser sends
+	// its requests through; credentials are taken from its user info.
+	Proxy *url.URL
+
+	// Logger receives the package's logs; nil means slog.Default().
+	Logger *slog.Logger
+}
+
+// The browser engines ParseOptions.Browser selects between.
+const (
+	BrowserChromium = "chromium"
+	BrowserFirefox  = "firefox"
+	BrowserWebKit   = "webkit"
+)
+
+// engine is a browser of one type and the contexts pooled in it. err is
+// kept when the browser failed to start, so that it isn't tried again for
+// every page.
+type engine struct {
+	browser  playwright.Browser
+	contexts *contextPool
+	err      error
 }
 
 var (
-	pw      *playwright.Playwright
-	browser playwright.Browser
-	once    sync.Once
-	initErr error
+	pw          *playwright.Playwright
+	engines     = make(map[string]*engine)
+	enginesMu   sync.Mutex
+	browserOpts BrowserOptions
+	once        sync.Once
+	initErr     error
+
+	cleanupMu sync.Mutex
 )
 
+// Configure sets how the browser is obtained and where the package logs.
+// It must be called before the first page is parsed; later calls have no
+// effect on a running browser.
+func Configure(opts BrowserOptions) {
+	browserOpts = opts
+}
+
+// browserProxy converts proxyURL to the browser's proxy settings, which
+// take the credentials separately; nil means no proxy.
+func browserProxy(proxyURL *url.URL) *playwright.Proxy {
+	if proxyURL == nil {
+		return nil
+	}
+	proxy := &playwright.Proxy{Server: (&url.URL{Scheme: proxyURL.Scheme, Host: proxyURL.Host}).String()}
+	if proxyURL.User != nil {
+		proxy.Username = playwright.String(proxyURL.User.Username())
+		if password, ok := proxyURL.User.Password(); ok {
+			proxy.Password = playwright.String(password)
+		}
+	}
+	return proxy
+}
+
+func logger() *slog.Logger {
+	if browserOpts.Logger != nil {
+		return browserOpts.Logger
+	}
+	return slog.Default()
+}
+
 func initPlaywright() error {
 	once.Do(func() {
 		runOpts := &playwright.RunOptions{
-			SkipInstallBrowsers: false,
+			SkipInstallBrowsers: browserOpts.WSEndpoint != "",
 		}
-		pw, err := playwright.Run(runOpts)
+		var err error
+		pw, err = playwright.Run(runOpts)
 		if err != nil {
 			initErr = fmt.Errorf("failed to start playwright: %v", err)
-			return
 		}
+	})
+	return initErr
+}
+
+// chromiumArgs are the launch flags for Chromium; the other engines don't
+// understand them.
+var chromiumArgs = []string{
+	"--disable-gpu",
+	"--no-sandbox",
+	"--disable-setuid-sandbox",
+	"--disable-web-security",
+	"--disable-features=IsolateOrigins,site-per-process",
+}
+
+// ValidBrowser reports whether name is a browser engine ParseOptions.Browser
+// accepts; empty means BrowserChromium.
+func ValidBrowser(name string) bool {
+	switch name {
+	case "", BrowserChromium, BrowserFirefox, BrowserWebKit:
+		return true
+	}
+	return false
+}
+
+// engineFor returns the browser named name (BrowserChromium when empty),
+// launching it, or connecting to WSEndpoint, the first time it is asked
+// for.
+func engineFor(name string) (*engine, error) {
+	if name == "" {
+		name = BrowserChromium
+	}
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	if e, ok := engines[name]; ok {
+		return e, e.err
+	}
+	if pw == nil {
+		return nil, fmt.Errorf("playwright has been stopped")
+	}
+
+	var browserType playwright.BrowserType
+	switch name {
+	case BrowserChromium:
+		browserType = pw.Chromium
+	case BrowserFirefox:
+		browserType = pw.Firefox
+	case BrowserWebKit:
+		browserType = pw.WebKit
+	default:
+		return nil, fmt.Errorf("unknown browser %q, want %s, %s or %s", name, BrowserChromium, BrowserFirefox, BrowserWebKit)
+	}
 
+	e := &engine{}
+	var err error
+	if browserOpts.WSEndpoint != "" {
+		// A browser connected to has been launched already; its proxy is
+		// set on each context instead.
+		logger().Debug("Connecting to browser", "endpoint", browserOpts.WSEndpoint)
+		if name != BrowserChromium {
+			e.err = fmt.Errorf("can't connect to %s over CDP, only to chromium", name)
+		} else if e.browser, err = browserType.ConnectOverCDP(browserOpts.WSEndpoint); err != nil {
+			e.err = fmt.Errorf("failed to connect to browser at %s: %v", browserOpts.WSEndpoint, err)
+		}
+	} else {
 		launchOpts := playwright.BrowserTypeLaunchOptions{
 			Headless: playwright.Bool(true),
-			Args: []string{
-				"--disable-gpu",
-				"--no-sandbox",
-				"--disable-setuid-sandbox",
-				"--disable-web-security",
-				"--disable-features=IsolateOrigins,site-per-process",
-			},
-		}
-		browser, err = pw.Chromium.Launch(launchOpts)
-		if err != nil {
-			initErr = fmt.Errorf("failed to launch browser: %v", err)
-			if pw != nil {
-				pw.Stop()
-			}
-			return
+			Proxy:    browserProxy(browserOpts.Proxy),
 		}
-	})
-	return initErr
+		if name == BrowserChromium {
+			launchOpts.Args = chromiumArgs
+		}
+		logger().Debug("Launching browser", "browser", name)
+		if e.browser, err = browserType.Launch(launchOpts); err != nil {
+			e.err = fmt.Errorf("failed to launch %s: %v", name, err)
+		}
+	}
+	if e.err == nil {
+		e.contexts = newContextPool(e.browser, browserOpts.PoolSize, browserOpts.ContextMaxAge, browserOpts.ContextMaxUses)
+	}
+	engines[name] = e
+	return e, e.err
 }
 
-func ParseWithPlaywright(url string) (ParseResult, error) {
+func ParseWithPlaywright(url string, opts ParseOptions) (ParseResult, error) {
 	if err := initPlaywright(); err != nil {
 		return ParseResult{}, fmt.Errorf("failed to initialize playwright: %v", err)
 	}
+	browserEngine, err := engineFor(opts.Browser)
+	if err != nil {
+		return ParseResult{}, fmt.Errorf("failed to initialize playwright: %v", err)
+	}
+	contexts := browserEngine.contexts
 
 	contextOpts := playwright.BrowserNewContextOptions{
 		JavaScriptEnabled: playwright.Bool(true),
-		UserAgent:         playwright.String("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"),
+		UserAgent:         playwright.String(DefaultUserAgent),
 		ExtraHttpHeaders: map[string]string{
-			"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8",
-			"Accept-Language": "en-US,en;q=0.5",
+			"Accept":          acceptHeader,
+			"Accept-Language": acceptLanguageHeader,
 		},
 	}
-	context, err := browser.NewContext(contextOpts)
+	if browserOpts.WSEndpoint != "" {
+		contextOpts.Proxy = browserProxy(browserOpts.Proxy)
+	}
+	if err := applyEmulation(&contextOpts, opts); err != nil {
+		return ParseResult{}, err
+	}
+	if opts.UserAgent != "" {
+		contextOpts.UserAgent = playwright.String(opts.UserAgent)
+	}
+	for name, value := range opts.Headers {
+		contextOpts.ExtraHttpHeaders[name] = value
+	}
+
+	pooled, err := contexts.get(contextOpts)
 	if err != nil {
-		return ParseResult{}, fmt.Errorf("failed to create browser context: %v", err)
+		return ParseResult{}, err
+	}
+	reusable := true
+	defer func() { contexts.put(pooled, reusable) }()
+	context := pooled.context
+
+	if len(opts.Cookies) > 0 {
+		cookies := make([]playwright.OptionalCookie, 0, len(opts.Cookies))
+		for name, value := range opts.Cookies {
+			cookies = append(cookies, playwright.OptionalCookie{
+				Name:  name,
+				Value: value,
+				URL:   playwright.String(url),
+			})
+		}
+		if err := context.AddCookies(cookies); err != nil {
+			return ParseResult{}, fmt.Errorf("failed to set cookies: %v", err)
+		}
 	}
-	defer context.Close()
 
 	page, err := context.NewPage()
 	if err != nil {
+		reusable = false
 		return ParseResult{}, fmt.Errorf("failed to create page: %v", err)
 	}
+	defer func() {
+		if err := page.Close(); err != nil {
+			logger().Warn("Failed to close page", "error", err)
+			reusable = false
+		}
+	}()
+
+	var route func(playwright.Route)
+	if opts.Transport != nil {
+		route = routeThrough(opts.Transport, opts.TransportDocumentsOnly)
+	}
+	if len(opts.BlockResourceTypes) > 0 {
+		route = blockResources(opts.BlockResourceTypes, route)
+	}
+	if route != nil {
+		if err := page.Route("**/*", route); err != nil {
+			return ParseResult{}, fmt.Errorf("failed to route browser requests: %v", err)
+		}
+	}
 
 	page.SetDefaultTimeout(45000) // 45 seconds
 	page.SetDefaultNavigationTimeout(45000)
 
-	log.Printf("DEBUG: Navigating to URL: %s\n", url)
-	if _, err := page.Goto(url, playwright.PageGotoOptions{
+	logger().Debug("Navigating to URL", "url", url)
+	navigation, err := page.Goto(url, playwright.PageGotoOptions{
 		WaitUntil: playwright.WaitUntilStateNetworkidle,
 		Timeout:   playwright.Float(30000),
-	}); err != nil {
-		return ParseResult{}, fmt.Errorf("failed to navigate to URL: %v", err)
+	})
+	if err != nil {
+		if isDownload(err) {
+			return ParseResult{Response: Response{URL: url}}, fmt.Errorf("%w: %v", ErrDownload, err)
+		}
+		if errors.Is(err, playwright.ErrTimeout) {
+			return ParseResult{}, fmt.Errorf("%w: %w", ErrNavigationTimeout, err)
+		}
+		return ParseResult{}, fmt.Errorf("failed to navigate to URL: %w", err)
+	}
+	response := describeResponse(navigation, page.URL())
+	logger().Debug("Response received", "url", url, "status", response.Status, "content_type", response.ContentType, "final_url", response.URL)
+	if opts.CheckResponse != nil {
+		if err := opts.CheckResponse(response); err != nil {
+			return ParseResult{Response: response}, err
+		}
+	}
+	if opts.MaxContentBytes > 0 && response.BodySize > opts.MaxContentBytes {
+		return ParseResult{Response: response}, fmt.Errorf("%w: %d bytes, limit %d", ErrContentTooLarge, response.BodySize, opts.MaxContentBytes)
+	}
+
+	if opts.HashRouting {
+		waitForHashRoute(page, url)
+	}
+	if err := waitForContent(page, opts); err != nil {
+		return ParseResult{Response: response}, err
 	}
 
-	log.Printf("DEBUG: Page loaded, waiting for content to be visible...")
+	var ampURL string
+	if opts.PreferAMP {
+		ampURL = switchToAMP(page, url)
+	}
 
-	log.Printf("DEBUG: Trying direct content extraction...")
-	contentHandle, err := page.EvaluateHandle(`() => {
-		try {
-			// Try to find the main content container
-			const selectors = [
-				'article',
-				'main article',
-				'.blog-content',
-				'.post-content',
-				'main',
-				'.content',
-				'#content',
-				'body'
-			];
-
-			let content = null;
-			for (const selector of selectors) {
-				content = document.querySelector(selector);
-				if (content) {
-					console.log('Found content using selector:', selector);
-					break;
+	var screenshotPath string
+	if opts.Screenshot {
+		screenshotPath = takeScreenshot(page, url, opts.ScreenshotDir)
+	}
+
+	published := extractPublished(page)
+	description, canonical, robots := extractMeta(page)
+	declaredLang := extractLang(page)
+
+	if opts.ExtractScript != "" {
+		result, err := extractCustom(page, opts.ExtractScript, ampURL)
+		result.ScreenshotPath = screenshotPath
+		result.Response = response
+		result.Published = published
+		result.Description = description
+		result.Canonical = canonical
+		result.RobotsMeta = robots
+		result.Lang = pageLang(declaredLang, result.Text, opts.DetectLang)
+		return result, err
+	}
+
+	logger().Debug("Page loaded, waiting for content to be visible")
+
+	logger().Debug("Trying direct content extraction")
+	contentSelectors := opts.ContentSelectors
+	if contentSelectors == nil {
+		contentSelectors = defaultContentSelectors
+	}
+	var contentStr, matchedSelector string
+	if opts.ExtractMode == ExtractReadability {
+		contentStr, matchedSelector = extractReadability(page, opts)
+	}
+	if contentStr == "" {
+		contentStr, matchedSelector, err = extractContent(page, contentSelectors, opts)
+		if err != nil {
+			return ParseResult{}, err
+		}
+	}
+
+	usedFallback := false
+	if len(contentStr) < opts.MinContentLength || contentStr == "" {
+		if fallback, selector := extractWithFallback(page, contentStr, opts); selector != "" {
+			usedFallback = true
+			contentStr, matchedSelector = fallback, selector
+		}
+	}
+
+	if opts.NoscriptFallback && (contentStr == "" || len(contentStr) < opts.MinContentLength) {
+		noscript, err := extractNoscript(page)
+		if err != nil {
+			logger().Warn("Failed to extract <noscript> content", "url", url, "error", err)
+		} else if len(noscript) > len(contentStr) {
+			logger().Debug("Using <noscript> content instead of rendered content", "noscript_chars", len(noscript), "rendered_chars", len(contentStr))
+			usedFallback = true
+			contentStr, matchedSelector = noscript, NoscriptSelector
+		}
+	}
+
+	var comments string
+	if opts.IncludeComments {
+		comments, err = extractComments(page)
+		if err != nil {
+			logger().Warn("Failed to extract comments", "url", url, "error", err)
+		}
+	}
+
+	logger().Debug("Extracting links")
+	linksList, err := extractLinks(page)
+	if err != nil {
+		return ParseResult{}, err
+	}
+
+	logger().Debug("Extracted content", "bytes", len(contentStr), "links", len(linksList))
+	if len(contentStr) > 0 {
+		logger().Debug("Start of content", "text", contentStr[:min(100, len(contentStr))])
+	}
+
+	title, err := page.Title()
+	if err != nil {
+		logger().Warn("Failed to read page title", "error", err)
+	}
+
+	return ParseResult{
+		Title:      strings.TrimSpace(title),
+		Text:       contentStr,
+		Links:      linksList,
+		Response:   response,
+		Confidence: extractionConfidence(contentStr, len(linksList), usedFallback, matchedSelector == BodySelector),
+
+		MatchedSelector: matchedSelector,
+		Comments:        comments,
+		AMPURL:          ampURL,
+		Published:       published,
+		Description:     description,
+		Canonical:       canonical,
+		RobotsMeta:      robots,
+		ScreenshotPath:  screenshotPath,
+		Lang:            pageLang(declaredLang, contentStr, opts.DetectLang),
+	}, nil
+}
+
+// describeResponse summarizes a navigation's response; resp is nil when
+// the browser reported none, and pageURL is then used as the final URL.
+func describeResponse(resp playwright.Response, pageURL string) Response {
+	if resp == nil {
+		return Response{URL: pageURL}
+	}
+	response := Response{
+		StatusCode:    resp.Status(),
+		Status:        strings.TrimSpace(fmt.Sprintf("%d %s", resp.Status(), resp.StatusText())),
+		URL:           resp.URL(),
+		ContentLength: -1,
+	}
+	if contentType, err := resp.HeaderValue("content-type"); err == nil {
+		response.ContentType = contentType
+	}
+	if retryAfter, err := resp.HeaderValue("retry-after"); err == nil {
+		response.RetryAfter = retryAfter
+	}
+	if lastModified, err := resp.HeaderValue("last-modified"); err == nil {
+		response.LastModified = lastModified
+	}
+	if length, err := resp.HeaderValue("content-length"); err == nil && length != "" {
+		if n, err := strconv.ParseInt(strings.TrimSpace(length), 10, 64); err == nil && n >= 0 {
+			response.ContentLength = n
+		}
+	}
+	if body, err := resp.Body(); err == nil {
+		response.BodySize = int64(len(body))
+	}
+	for request := resp.Request().RedirectedFrom(); request != nil; request = request.RedirectedFrom() {
+		response.Redirects++
+	}
+	return response
+}
+
+// routeThrough returns a route handler that fulfills the browser's
+// requests using transport, or with documentsOnly only its document
+// requests, letting the rest through to the network.
+func routeThrough(transport http.RoundTripper, documentsOnly bool) func(playwright.Route) {
+	return func(route playwright.Route) {
+		request := route.Request()
+		if documentsOnly && request.ResourceType() != "document" {
+			if err := route.Continue(); err != nil {
+				logger().Warn("Failed to continue browser request", "url", request.URL(), "error", err)
+			}
+			return
+		}
+
+		var body io.Reader
+		if data, err := request.PostDataBuffer(); err == nil && len(data) > 0 {
+			body = bytes.NewReader(data)
+		}
+		req, err := http.NewRequest(request.Method(), request.URL(), body)
+		if err != nil {
+			logger().Warn("Failed to build request", "url", request.URL(), "error", err)
+			route.Abort()
+			return
+		}
+		if headers, err := request.AllHeaders(); err == nil {
+			for name, value := range headers {
+				// Leave compression to the transport so bodies arrive decoded.
+				if strings.HasPrefix(name, ":") || strings.EqualFold(name, "Accept-Encoding") {
+					continue
 				}
+				req.Header.Set(name, value)
 			}
+		}
+
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			logger().Warn("Browser request failed", "url", request.URL(), "error", err)
+			route.Abort()
+			return
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			logger().Warn("Failed to read response", "url", request.URL(), "error", err)
+			route.Abort()
+			return
+		}
+
+		headers := make(map[string]string, len(resp.Header))
+		for name, values := range resp.Header {
+			headers[name] = strings.Join(values, "\n")
+		}
+		if err := route.Fulfill(playwright.RouteFulfillOptions{
+			Status:  playwright.Int(resp.StatusCode),
+			Headers: headers,
+			Body:    data,
+		}); err != nil {
+			logger().Warn("Failed to fulfill browser request", "url", request.URL(), "error", err)
+		}
+	}
+}
 
-			if (!content) {
-				console.warn('No content element found');
-				return '';
+// blockResources returns a route handler that aborts requests for the
+// given resource types and passes the rest to next, or to the network when
+// next is nil.
+func blockResources(types []string, next func(playwright.Route)) func(playwright.Route) {
+	blocked := make(map[string]bool, len(types))
+	for _, resourceType := range types {
+		blocked[strings.ToLower(resourceType)] = true
+	}
+	return func(route playwright.Route) {
+		request := route.Request()
+		if blocked[request.ResourceType()] {
+			if err := route.Abort("blockedbyclient"); err != nil {
+				logger().Warn("Failed to block browser request", "url", request.URL(), "error", err)
 			}
+			return
+		}
+		if next != nil {
+			next(route)
+			return
+		}
+		if err := route.Continue(); err != nil {
+			logger().Warn("Failed to continue browser request", "url", request.URL(), "error", err)
+		}
+	}
+}
 
-			// Create a copy of the content to manipulate
-			const clone = content.cloneNode(true);
-
-			// Remove non-content elements
-			[
-				'script',
-				'style',
-				'pre',
-				'code',
-				'nav',
-				'footer',
-				'header',
-				'aside',
-				'#skip-to-main',
-				'.skip-to-main',
-				'.navigation',
-				'.nav-menu',
-				'.menu',
-				'.sidebar',
-				'.table-of-contents',
-				'.social-share',
-				'.share-buttons',
-				'.comments',
-				'.comment-section',
-				'.site-header',
-				'.site-footer',
-				'.site-navigation',
-				'.breadcrumbs'
-			].forEach(selector => {
-				const elements = clone.querySelectorAll(selector);
-				console.log('Removing', elements.length, selector, 'elements');
-				elements.forEach(el => el.remove());
-			});
-
-			// Get text content and clean it up
-			let text = clone.textContent;
-
-			// Clean up the text in multiple steps
-			text = text.replace(/\s+/g, ' ');  // Replace multiple whitespace with single space
-			text = text.replace(/^\s+|\s+$/g, '');  // Trim whitespace
-			text = text.replace(/Skip to (?:main )?content/gi, '');  // Remove "Skip to content" text
-			text = text.replace(/Home\s*Blogs\s*Thoughts/g, '');  // Remove navigation text
-			text = text.replace(/\s*- RAG evaluation/g, '');  // Remove title duplication
-			text = text.replace(/rag_evaluation\/?/g, '');  // Remove URL fragments
-			text = text.replace(/\s{3,}/g, '\n\n');  // Replace 3+ spaces with newlines
-			text = text.trim();
-
-			// Add some structure back
-			text = text.split(/\n{2,}/)  // Split on multiple newlines
-				.filter(para => para.trim().length > 0)  // Remove empty paragraphs
-				.map(para => para.trim())  // Trim each paragraph
-				.join('\n\n');  // Join with double newlines
-
-			console.log('Successfully extracted content:', text.substring(0, 100) + '...');
-			return text;
-		} catch (error) {
-			console.error('Error extracting content:', error);
-			return '';
+// waitForHashRoute waits for a single-page app to render the hash route in
+// pageURL, if it has one: until location.hash matches and the page shows
+// some text, then for the network to settle again.
+func waitForHashRoute(page playwright.Page, pageURL string) {
+	_, fragment, _ := strings.Cut(pageURL, "#")
+	if !strings.HasPrefix(fragment, "/") && !strings.HasPrefix(fragment, "!/") {
+		return
+	}
+
+	logger().Debug("Waiting for hash route to render", "fragment", fragment)
+	if _, err := page.WaitForFunction(`(hash) => location.hash === hash && document.body && document.body.innerText.trim().length > 0`, "#"+fragment, playwright.PageWaitForFunctionOptions{
+		Timeout: playwright.Float(10000),
+	}); err != nil {
+		logger().Warn("Hash route did not render", "fragment", fragment, "error", err)
+		return
+	}
+	if err := page.WaitForLoadState(playwright.PageWaitForLoadStateOptions{
+		State:   playwright.LoadStateNetworkidle,
+		Timeout: playwright.Float(10000),
+	}); err != nil {
+		logger().Warn("Network did not settle after hash route", "fragment", fragment, "error", err)
+	}
+}
+
+// waitForContent waits for opts.WaitForSelector to appear and then for
+// opts.WaitForTimeout.
+func waitForContent(page playwright.Page, opts ParseOptions) error {
+	if opts.WaitForSelector != "" {
+		logger().Debug("Waiting for selector", "selector", opts.WaitForSelector)
+		if _, err := page.WaitForSelector(opts.WaitForSelector, playwright.PageWaitForSelectorOptions{
+			Timeout: playwright.Float(float64(waitForSelectorTimeout.Milliseconds())),
+		}); err != nil {
+			return fmt.Errorf("%w: %q not visible after %v: %v", ErrSelectorNotFound, opts.WaitForSelector, waitForSelectorTimeout, err)
 		}
-	}`)
+	}
+	if opts.WaitForTimeout > 0 {
+		page.WaitForTimeout(float64(opts.WaitForTimeout.Milliseconds()))
+	}
+	return nil
+}
+
+// CustomScriptSelector is reported as the matched selector for pages
+// extracted with ParseOptions.ExtractScript.
+const CustomScriptSelector = "custom script"
+
+// extractCustom runs a user-supplied extraction script and checks that it
+// returned {text, links}. Its links all count as content links.
+func extractCustom(page playwright.Page, script, ampURL string) (ParseResult, error) {
+	logger().Debug("Running custom extraction script")
+	value, err := page.Evaluate(script)
 	if err != nil {
-		return ParseResult{}, fmt.Errorf("failed to extract content: %v", err)
+		return ParseResult{}, fmt.Errorf("custom extract script failed: %v", err)
 	}
-	defer contentHandle.Dispose()
 
-	content, err := contentHandle.JSONValue()
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return ParseResult{}, fmt.Errorf("custom extract script must return an object {text, links}, got %T", value)
+	}
+	text, ok := fields["text"].(string)
+	if !ok {
+		return ParseResult{}, fmt.Errorf("custom extract script must return a string text field, got %T", fields["text"])
+	}
+	rawLinks, ok := fields["links"].([]interface{})
+	if !ok && fields["links"] != nil {
+		return ParseResult{}, fmt.Errorf("custom extract script must return links as an array of strings, got %T", fields["links"])
+	}
+
+	var links []Link
+	for i, raw := range rawLinks {
+		href, ok := raw.(string)
+		if !ok {
+			return ParseResult{}, fmt.Errorf("custom extract script returned a %T at links[%d], expected a string", raw, i)
+		}
+		if href != "" {
+			links = append(links, Link{URL: href, InContent: true})
+		}
+	}
+
+	title, err := page.Title()
 	if err != nil {
-		return ParseResult{}, fmt.Errorf("failed to get content value: %v", err)
+		logger().Warn("Failed to read page title", "error", err)
 	}
 
-	log.Printf("DEBUG: Extracting links...")
-	linksHandle, err := page.EvaluateHandle(`() => {
-		try {
-			const links = document.querySelectorAll('a[href]');
-			console.log('Found', links.length, 'links');
-			const extractedLinks = Array.from(links)
-				.map(link => link.href)
-				.filter(href => href && (href.startsWith('http://') || href.startsWith('https://')))
-				.filter(href => !href.includes('javascript:'))
-				.filter((href, index, self) => self.indexOf(href) === index); // Remove duplicates
-			console.log('After filtering:', extractedLinks.length, 'links remain');
-			return extractedLinks;
-		} catch (error) {
-			console.error('Error extracting links:', error);
-			return [];
+	text = strings.TrimSpace(text)
+	logger().Debug("Custom script extracted content", "bytes", len(text), "links", len(links))
+	return ParseResult{
+		Title:      strings.TrimSpace(title),
+		Text:       text,
+		Links:      links,
+		Confidence: extractionConfidence(text, len(links), false, false),
+
+		MatchedSelector: CustomScriptSelector,
+		AMPURL:          ampURL,
+	}, nil
+}
+
+// applyEmulation sets the device, viewport and scale factor from opts on
+// the context options.
+func applyEmulation(contextOpts *playwright.BrowserNewContextOptions, opts ParseOptions) error {
+	if opts.Device != "" {
+		device := lookupDevice(opts.Device)
+		if device == nil {
+			return fmt.Errorf("unknown device to emulate: %q", opts.Device)
+		}
+		contextOpts.UserAgent = playwright.String(device.UserAgent)
+		contextOpts.Viewport = device.Viewport
+		contextOpts.Screen = device.Screen
+		contextOpts.DeviceScaleFactor = playwright.Float(device.DeviceScaleFactor)
+		contextOpts.IsMobile = playwright.Bool(device.IsMobile)
+		contextOpts.HasTouch = playwright.Bool(device.HasTouch)
+	}
+
+	if opts.Viewport.Width > 0 && opts.Viewport.Height > 0 {
+		contextOpts.Viewport = &playwright.Size{
+			Width:  opts.Viewport.Width,
+			Height: opts.Viewport.Height,
+		}
+	}
+	if opts.DeviceScaleFactor > 0 {
+		contextOpts.DeviceScaleFactor = playwright.Float(opts.DeviceScaleFactor)
+	}
+	return nil
+}
+
+// lookupDevice finds a Playwright device descriptor by name, ignoring case.
+func lookupDevice(name string) *playwright.DeviceDescriptor {
+	if device, ok := pw.Devices[name]; ok {
+		return device
+	}
+	for deviceName, device := range pw.Devices {
+		if strings.EqualFold(deviceName, name) {
+			return device
 		}
+	}
+	return nil
+}
+
+// switchToAMP navigates page to the AMP version url declares, if any, and
+// returns its URL. When there is none, or it fails to load, page is left on
+// (or returned to) url and "" is returned.
+func switchToAMP(page playwright.Page, url string) string {
+	href, err := page.Evaluate(`() => {
+		const link = document.querySelector('link[rel="amphtml"][href]');
+		return link ? link.href : '';
 	}`)
 	if err != nil {
-		return ParseResult{}, fmt.Errorf("failed to extract links: %v", err)
+		logger().Warn("Failed to look up AMP version", "url", url, "error", err)
+		return ""
+	}
+	ampURL, _ := href.(string)
+	if ampURL == "" || ampURL == url {
+		return ""
 	}
-	defer linksHandle.Dispose()
 
-	links, err := linksHandle.JSONValue()
+	logger().Debug("Navigating to AMP version", "url", ampURL)
+	if _, err := page.Goto(ampURL, playwright.PageGotoOptions{
+		WaitUntil: playwright.WaitUntilStateNetworkidle,
+		Timeout:   playwright.Float(30000),
+	}); err != nil {
+		logger().Warn("Failed to load AMP version, using the original", "amp_url", ampURL, "url", url, "error", err)
+		if _, err := page.Goto(url, playwright.PageGotoOptions{
+			WaitUntil: playwright.WaitUntilStateNetworkidle,
+			Timeout:   playwright.Float(30000),
+		}); err != nil {
+			logger().Warn("Failed to return to page", "url", url, "error", err)
+		}
+		return ""
+	}
+	return ampURL
+}
+
+// Link is a link found on a page, tagged with where on the page it
+// appeared. A link repeated in both places has both flags set.
+type Link struct {
+	URL string
+	// InContent is set for links inside the main content element,
+	// InNavigation for links in the site chrome (menus, header, footer,
+	// sidebars).
+	InContent    bool
+	InNavigation bool
+
+	// NoFollow is set when every occurrence of the link has
+	// rel="nofollow" (or "ugc" or "sponsored").
+	NoFollow bool
+}
+
+// extractComments returns the text of the page's comment sections, one
+// paragraph per comment where they can be told apart.
+func extractComments(page playwright.Page) (string, error) {
+	value, err := page.Evaluate(extractCommentsScript)
 	if err != nil {
-		return ParseResult{}, fmt.Errorf("failed to get links value: %v", err)
+		return "", fmt.Errorf("failed to extract comments: %v", err)
 	}
+	text, _ := value.(string)
+	return strings.TrimSpace(text), nil
+}
+
+// extractLinks returns the page's distinct http(s) links.
+func extractLinks(page playwright.Page) ([]Link, error) {
+	linksHandle, err := page.EvaluateHandle(extractLinksScript, contentRootSelectors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract links: %v", err)
+	}
+	defer linksHandle.Dispose()
 
-	contentStr := ""
-	if content != nil {
-		contentStr = strings.TrimSpace(content.(string))
+	links, err := linksHandle.JSONValue()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get links value: %v", err)
 	}
 
-	var linksList []string
+	var linksList []Link
 	if linksArr, ok := links.([]interface{}); ok {
 		for _, link := range linksArr {
-			if linkStr, ok := link.(string); ok {
-				linksList = append(linksList, linkStr)
+			fields, ok := link.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			href, _ := fields["href"].(string)
+			if href == "" {
+				continue
 			}
+			inContent, _ := fields["inContent"].(bool)
+			inNavigation, _ := fields["inNavigation"].(bool)
+			noFollow, _ := fields["nofollow"].(bool)
+			linksList = append(linksList, Link{URL: href, InContent: inContent, InNavigation: inNavigation, NoFollow: noFollow})
 		}
 	}
+	return linksList, nil
+}
 
-	log.Printf("DEBUG: Extracted %d bytes of content and %d links\n", len(contentStr), len(linksList))
-	if len(contentStr) > 0 {
-		log.Printf("DEBUG: First 100 chars of content: %s\n", contentStr[:min(100, len(contentStr))])
+// contentRootSelectors locate the main content element when tagging links;
+// body is left out since it would make every link a content link.
+var contentRootSelectors = []string{
+	"article",
+	"main article",
+	".blog-content",
+	".post-content",
+	"main",
+	"[role=\"main\"]",
+	".content",
+	"#content",
+}
+
+// BodySelector is the last-resort content selector.
+const BodySelector = "body"
+
+// defaultContentSelectors are tried in order; the first matching element is
+// treated as the page's main content.
+var defaultContentSelectors = []string{
+	"article",
+	"main article",
+	".blog-content",
+	".post-content",
+	"main",
+	".content",
+	"#content",
+	"body",
+}
+
+// defaultFallbackSelectors are progressively broader containers retried one
+// at a time when the primary extraction comes back empty or too short.
+var defaultFallbackSelectors = []string{
+	"[role=\"main\"]",
+	"main",
+	"#main",
+	".main",
+	"#content",
+	".content",
+	"body",
+}
+
+// defaultRemoveSelectors match the non-content elements stripped from the
+// content element.
+var defaultRemoveSelectors = []string{
+	"script",
+	"style",
+	"pre",
+	"code",
+	"nav",
+	"footer",
+	"header",
+	"aside",
+	"#skip-to-main",
+	".skip-to-main",
+	".navigation",
+	".nav-menu",
+	".menu",
+	".sidebar",
+	".table-of-contents",
+	".social-share",
+	".share-buttons",
+	".site-header",
+	".site-footer",
+	".site-navigation",
+	".breadcrumbs",
+}
+
+// extractReadability runs readabilityContent on the rendered page.
+func extractReadability(page playwright.Page, opts ParseOptions) (string, string) {
+	content, err := page.Content()
+	if err != nil {
+		logger().Warn("Failed to read rendered page for readability extraction", "error", err)
+		return "", ""
+	}
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		logger().Warn("Failed to parse rendered page for readability extraction", "error", err)
+		return "", ""
 	}
+	return readabilityContent(doc, opts)
+}
 
-	return ParseResult{
-		Text:  contentStr,
-		Links: linksList,
-	}, nil
+// extractContent runs the content extraction script using the first of
+// selectors that matches an element, returning the text and that selector.
+func extractContent(page playwright.Page, selectors []string, opts ParseOptions) (string, string, error) {
+	removeSelectors := opts.RemoveSelectors
+	if removeSelectors == nil {
+		removeSelectors = defaultRemoveSelectors
+	}
+	contentHandle, err := page.EvaluateHandle(extractContentScript, map[string]interface{}{
+		"selectors":         selectors,
+		"removeSelectors":   removeSelectors,
+		"preserveStructure": opts.PreserveStructure,
+		"includeComments":   opts.IncludeComments,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to extract content: %v", err)
+	}
+	defer contentHandle.Dispose()
+
+	content, err := contentHandle.JSONValue()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get content value: %v", err)
+	}
+
+	fields, _ := content.(map[string]interface{})
+	text, _ := fields["text"].(string)
+	selector, _ := fields["selector"].(string)
+	return strings.TrimSpace(text), selector, nil
+}
+
+// extractWithFallback retries extraction with each fallback selector and
+// returns the first result of at least MinContentLength characters, with
+// its selector. The original content is kept, and "" returned as the
+// selector, when no fallback does better.
+func extractWithFallback(page playwright.Page, content string, opts ParseOptions) (string, string) {
+	fallbacks := opts.FallbackSelectors
+	if fallbacks == nil {
+		fallbacks = defaultFallbackSelectors
+	}
+
+	for _, selector := range fallbacks {
+		logger().Debug("Extracted little content, retrying with fallback selector", "chars", len(content), "selector", selector)
+		text, _, err := extractContent(page, []string{selector}, opts)
+		if err != nil {
+			logger().Warn("Fallback extraction failed", "selector", selector, "error", err)
+			continue
+		}
+		if text != "" && len(text) >= opts.MinContentLength {
+			return text, selector
+		}
+	}
+	return content, ""
 }
 
+// Cleanup closes the pooled browser contexts and the browsers and stops
+// Playwright. It is safe to call more than once and when the browser was
+// never started.
 func Cleanup() {
-	if browser != nil {
-		if err := browser.Close(); err != nil {
-			log.Printf("ERROR: Failed to close browser: %v", err)
+	cleanupMu.Lock()
+	defer cleanupMu.Unlock()
+
+	enginesMu.Lock()
+	for name, e := range engines {
+		if e.contexts != nil {
+			e.contexts.drain()
+		}
+		if e.browser != nil {
+			if err := e.browser.Close(); err != nil {
+				logger().Error("Failed to close browser", "browser", name, "error", err)
+			}
 		}
+		delete(engines, name)
 	}
+	enginesMu.Unlock()
 	if pw != nil {
 		if err := pw.Stop(); err != nil {
-			log.Printf("ERROR: Failed to stop playwright: %v", err)
+			logger().Error("Failed to stop playwright", "error", err)
 		}
+		pw = nil
+	}
+}
+
+// defaultScreenshotDir is where screenshots go when ParseOptions doesn't
+// say.
+const defaultScreenshotDir = "screenshots"
+
+// takeScreenshot saves a full-page PNG of page to dir, named by the SHA-256
+// of pageURL so that any URL gives a safe, distinct file name, and returns
+// its path, or "" if the capture failed.
+func takeScreenshot(page playwright.Page, pageURL, dir string) string {
+	if dir == "" {
+		dir = defaultScreenshotDir
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		logger().Warn("Failed to create screenshot directory", "dir", dir, "error", err)
+		return ""
+	}
+	sum := sha256.Sum256([]byte(pageURL))
+	path := filepath.Join(dir, hex.EncodeToString(sum[:])+".png")
+	if _, err := page.Screenshot(playwright.PageScreenshotOptions{
+		Path:     playwright.String(path),
+		FullPage: playwright.Bool(true),
+	}); err != nil {
+		logger().Warn("Failed to take screenshot", "url", pageURL, "error", err)
+		return ""
+	}
+	logger().Debug("Saved screenshot", "url", pageURL, "path", path)
+	return path
+}
+
+// extractPublished returns the publication date from the page's metadata,
+// or "" when it declares none.
+func extractPublished(page playwright.Page) string {
+	value, err := page.Evaluate(extractPublishedScript)
+	if err != nil {
+		logger().Warn("Failed to read publication date", "error", err)
+		return ""
+	}
+	published, _ := value.(string)
+	return strings.TrimSpace(published)
+}
+
+// extractMeta returns the page's meta description and canonical URL, or
+// "" for whichever it doesn't declare, and its robots directives.
+func extractMeta(page playwright.Page) (string, string, RobotsMeta) {
+	value, err := page.Evaluate(extractMetaScript)
+	if err != nil {
+		logger().Warn("Failed to read page metadata", "error", err)
+		return "", "", RobotsMeta{}
+	}
+	fields, _ := value.(map[string]interface{})
+	description, _ := fields["description"].(string)
+	canonical, _ := fields["canonical"].(string)
+	robots, _ := fields["robots"].(string)
+	return strings.TrimSpace(description), strings.TrimSpace(canonical), ParseRobotsMeta(robots)
+}
+
+// extractLang returns the page's <html lang> attribute, or "".
+func extractLang(page playwright.Page) string {
+	value, err := page.Evaluate(`() => document.documentElement.lang || ''`)
+	if err != nil {
+		logger().Warn("Failed to read page language", "error", err)
+		return ""
 	}
+	lang, _ := value.(string)
+	return lang
 }
 
+const extractMetaScript = `() => {
+	const meta = document.querySelector('meta[name="description" i][content]') ||
+		document.querySelector('meta[property="og:description"][content]');
+	const link = document.querySelector('link[rel~="canonical" i][href]');
+	const robots = Array.from(document.querySelectorAll('meta[name="robots" i][content]')).map(el => el.content);
+	return {
+		description: meta ? meta.content : '',
+		canonical: link ? link.href : '',
+		robots: robots.join(','),
+	};
+}`
+
+// extractPublishedScript looks for a publication date in the usual meta
+// tags, then JSON-LD, then the first <time datetime> in the article.
+const extractPublishedScript = `() => {
+	const meta = document.querySelector('meta[property="article:published_time"], meta[itemprop="datePublished"], meta[name="date"], meta[name="DC.date.issued"]');
+	if (meta && meta.content) return meta.content;
+	for (const script of document.querySelectorAll('script[type="application/ld+json"]')) {
+		try {
+			const data = JSON.parse(script.textContent);
+			for (const node of [].concat(data, data['@graph'] || [])) {
+				if (node && node.datePublished) return String(node.datePublished);
+			}
+		} catch (error) {
+			// Ignore malformed JSON-LD.
+		}
+	}
+	const time = document.querySelector('article time[datetime], time[itemprop="datePublished"]');
+	return time ? time.getAttribute('datetime') : '';
+}`
+
+// NoscriptSelector is reported as the matched selector when the content
+// came from <noscript> elements.
+const NoscriptSelector = "noscript"
+
+func extractNoscript(page playwright.Page) (string, error) {
+	value, err := page.Evaluate(extractNoscriptScript)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract noscript content: %v", err)
+	}
+	text, _ := value.(string)
+	return strings.TrimSpace(text), nil
+}
+
+// extractNoscriptScript returns the text of the page's <noscript> elements.
+// With JavaScript enabled their contents are raw markup, so each is parsed
+// on its own before its text is taken.
+const extractNoscriptScript = `() => {
+	const parser = new DOMParser();
+	const clean = text => text.replace(/[ \t]+/g, ' ').replace(/\s*\n\s*/g, '\n').trim();
+	return Array.from(document.querySelectorAll('noscript')).map(el => {
+		const doc = parser.parseFromString(el.textContent, 'text/html');
+		doc.querySelectorAll('script, style, img, iframe').forEach(node => node.remove());
+		return clean(doc.body ? doc.body.textContent : '');
+	}).filter(text => text.length > 0).join('\n\n');
+}`
+
+// extractCommentsScript returns the text of the outermost comment sections,
+// with each individual comment on its own paragraph when they are marked up
+// as such.
+const extractCommentsScript = `() => {
+	const sections = Array.from(document.querySelectorAll('#comments, .comments, .comment-section, [data-testid="comments"]'))
+		.filter((el, _, all) => !all.some(other => other !== el && other.contains(el)));
+	const clean = text => text.replace(/\s+/g, ' ').trim();
+	return sections.map(section => {
+		const comments = section.querySelectorAll('.comment-body, .comment-content, .comment, article, li');
+		if (comments.length === 0) {
+			return clean(section.textContent);
+		}
+		return Array.from(comments)
+			.filter((el, _, all) => !all.some(other => other !== el && other.contains(el)))
+			.map(el => clean(el.textContent))
+			.filter(text => text.length > 0)
+			.join('\n\n');
+	}).filter(text => text.length > 0).join('\n\n');
+}`
+
+// extractLinksScript collects distinct http(s) links, tagging each as
+// content (inside the first element matching one of the selectors passed
+// in, and not in a nav-like element within it) or navigation, and as
+// nofollow when no occurrence of it may be followed.
+const extractLinksScript = `(selectors) => {
+	try {
+		let root = null;
+		for (const selector of selectors) {
+			root = document.querySelector(selector);
+			if (root) break;
+		}
+		const chrome = 'nav, header, footer, aside, [role="navigation"], [role="banner"], [role="contentinfo"], .menu, .nav, .navbar, .sidebar, .breadcrumb';
+
+		const found = new Map();
+		for (const link of document.querySelectorAll('a[href]')) {
+			const href = link.href;
+			if (!href || !(href.startsWith('http://') || href.startsWith('https://'))) continue;
+
+			const nav = link.closest(chrome);
+			const inContent = root ? (root.contains(link) && !(nav && root.contains(nav))) : !nav;
+			const nofollow = /(^|\s)(nofollow|ugc|sponsored)(\s|$)/i.test(link.getAttribute('rel') || '');
+			const entry = found.get(href) || { href: href, inContent: false, inNavigation: false, nofollow: true };
+			entry.nofollow = entry.nofollow && nofollow;
+			if (inContent) {
+				entry.inContent = true;
+			} else {
+				entry.inNavigation = true;
+			}
+			found.set(href, entry);
+		}
+		return Array.from(found.values());
+	} catch (error) {
+		console.error('Error extracting links:', error);
+		return [];
+	}
+}`
+
+const extractContentScript = `(options) => {
+	try {
+		// Try to find the main content container
+		const selectors = options.selectors;
+
+		let content = null;
+		let matched = '';
+		for (const selector of selectors) {
+			content = document.querySelector(selector);
+			if (content) {
+				console.log('Found content using selector:', selector);
+				matched = selector;
+				break;
+			}
+		}
+
+		if (!content) {
+			console.warn('No content element found');
+			return { text: '', selector: '' };
+		}
+
+		// Create a copy of the content to manipulate
+		const clone = content.cloneNode(true);
+
+		// Remove non-content elements
+		options.removeSelectors.concat(options.includeComments ? [] : ['.comments', '.comment-section']).forEach(selector => {
+			const elements = clone.querySelectorAll(selector);
+			console.log('Removing', elements.length, selector, 'elements');
+			elements.forEach(el => el.remove());
+		});
+
+		if (options.preserveStructure) {
+			return { text: toMarkdown(clone), selector: matched };
+		}
+
+		// Get text content and clean it up
+		let text = clone.textContent;
+
+		// Clean up the text in multiple steps
+		text = text.replace(/\s+/g, ' ');  // Replace multiple whitespace with single space
+		text = text.replace(/^\s+|\s+$/g, '');  // Trim whitespace
+		text = text.replace(/Skip to (?:main )?content/gi, '');  // Remove "Skip to content" text
+		text = text.replace(/\s{3,}/g, '\n\n');  // Replace 3+ spaces with newlines
+		text = text.trim();
+
+		// Add some structure back
+		text = text.split(/\n{2,}/)  // Split on multiple newlines
+			.filter(para => para.trim().length > 0)  // Remove empty paragraphs
+			.map(para => para.trim())  // Trim each paragraph
+			.join('\n\n');  // Join with double newlines
+
+		console.log('Successfully extracted content:', text.substring(0, 100) + '...');
+		return { text: text, selector: matched };
+	} catch (error) {
+		console.error('Error extracting content:', error);
+		return { text: '', selector: '' };
+	}
+
+	// Walk the element tree emitting headings as Markdown headings, list
+	// items as (nested) bullets and every other block as a paragraph.
+	function toMarkdown(root) {
+		const blockTags = new Set([
+			'p', 'div', 'section', 'article', 'main', 'blockquote', 'table',
+			'tr', 'figure', 'figcaption', 'dl', 'dt', 'dd', 'br', 'hr'
+		]);
+		const blocks = [];
+		let inline = '';
+		let prefix = '';
+		let listItem = false;
+
+		const flush = () => {
+			const text = inline.replace(/\s+/g, ' ').trim();
+			if (text) {
+				blocks.push({ text: prefix + text, list: listItem });
+			}
+			inline = '';
+			prefix = '';
+			listItem = false;
+		};
+
+		const walk = (node, listDepth) => {
+			if (node.nodeType === Node.TEXT_NODE) {
+				inline += node.textContent;
+				return;
+			}
+			if (node.nodeType !== Node.ELEMENT_NODE) {
+				return;
+			}
+
+			const tag = node.tagName.toLowerCase();
+			const heading = /^h([1-6])$/.exec(tag);
+			if (heading) {
+				flush();
+				inline = node.textContent;
+				prefix = '#'.repeat(Number(heading[1])) + ' ';
+				flush();
+				return;
+			}
+			if (tag === 'ul' || tag === 'ol') {
+				flush();
+				node.childNodes.forEach(child => walk(child, listDepth + 1));
+				flush();
+				return;
+			}
+			if (tag === 'li') {
+				flush();
+				prefix = '  '.repeat(Math.max(listDepth - 1, 0)) + '- ';
+				listItem = true;
+				node.childNodes.forEach(child => walk(child, listDepth));
+				flush();
+				return;
+			}
+
+			const isBlock = blockTags.has(tag);
+			if (isBlock) {
+				flush();
+			}
+			node.childNodes.forEach(child => walk(child, listDepth));
+			if (isBlock) {
+				flush();
+			}
+		};
+
+		walk(root, 0);
+		flush();
+
+		// Consecutive list items stay on adjacent lines; everything else
+		// is separated by a blank line.
+		return blocks.reduce((out, block, i) => {
+			if (i === 0) {
+				return block.text;
+			}
+			const sep = block.list && blocks[i - 1].list ? '\n' : '\n\n';
+			return out + sep + block.text;
+		}, '');
+	}
+}`
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// defaultPoolSize is the number of browser contexts kept when
+// BrowserOptions.PoolSize is not set.
+const defaultPoolSize = 4
+
+// pooledContext is a browser context that is reused across pages created
+// with the same context options.
+type pooledContext struct {
+	context playwright.BrowserContext
+	key     string
+	created time.Time
+	uses    int
+}
+
+// contextPool hands out browser contexts to pages. At most size contexts
+// are in use at once; idle ones are kept for reuse by pages with the same
+// options until they reach maxAge or maxUses.
+type contextPool struct {
+	browser playwright.Browser
+	size    int
+	maxAge  time.Duration
+	maxUses int
+	slots   chan struct{}
+
+	mu     sync.Mutex
+	idle   []*pooledContext
+	closed bool
+}
+
+func newContextPool(browser playwright.Browser, size int, maxAge time.Duration, maxUses int) *contextPool {
+	if size <= 0 {
+		size = defaultPoolSize
+	}
+	return &contextPool{
+		browser: browser,
+		size:    size,
+		maxAge:  maxAge,
+		maxUses: maxUses,
+		slots:   make(chan struct{}, size),
+	}
+}
+
+// contextKey identifies contexts that can be shared: only pages with the
+// same user agent, headers and emulation reuse a context.
+func contextKey(opts playwright.BrowserNewContextOptions) (string, error) {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode browser context options: %v", err)
+	}
+	return string(data), nil
+}
+
+func (pc *pooledContext) expired(maxAge time.Duration, maxUses int) bool {
+	return (maxUses > 0 && pc.uses >= maxUses) || (maxAge > 0 && time.Since(pc.created) > maxAge)
+}
+
+// get checks out a context with opts, reusing an idle one when possible.
+// It blocks while size contexts are in use. The context must be returned
+// with put.
+func (p *contextPool) get(opts playwright.BrowserNewContextOptions) (*pooledContext, error) {
+	key, err := contextKey(opts)
+	if err != nil {
+		return nil, err
+	}
+	p.slots <- struct{}{}
+
+	var stale []*pooledContext
+	var reused *pooledContext
+	p.mu.Lock()
+	idle := p.idle[:0]
+	for _, pc := range p.idle {
+		switch {
+		case pc.expired(p.maxAge, p.maxUses):
+			stale = append(stale, pc)
+		case reused == nil && pc.key == key:
+			reused = pc
+		default:
+			idle = append(idle, pc)
+		}
+	}
+	p.idle = idle
+	if reused == nil {
+		// Make room for the new context by closing the least recently
+		// used idle ones.
+		for len(p.idle) > 0 && len(p.idle)+len(p.slots) > p.size {
+			stale = append(stale, p.idle[0])
+			p.idle = p.idle[1:]
+		}
+	}
+	p.mu.Unlock()
+
+	for _, pc := range stale {
+		pc.close()
+	}
+
+	if reused != nil {
+		// Cookies set by the previous page must not leak into this one.
+		if err := reused.context.ClearCookies(); err != nil {
+			logger().Warn("Failed to clear cookies of pooled browser context", "error", err)
+			reused.close()
+		} else {
+			reused.uses++
+			return reused, nil
+		}
+	}
+
+	context, err := p.browser.NewContext(opts)
+	if err != nil {
+		<-p.slots
+		return nil, fmt.Errorf("failed to create browser context: %v", err)
+	}
+	return &pooledContext{context: context, key: key, created: time.Now(), uses: 1}, nil
+}
+
+// put returns pc to the pool. Contexts that are expired, or that the page
+// left in a bad state (reusable false), are closed instead.
+func (p *contextPool) put(pc *pooledContext, reusable bool) {
+	p.mu.Lock()
+	keep := reusable && !p.closed && !pc.expired(p.maxAge, p.maxUses)
+	if keep {
+		p.idle = append(p.idle, pc)
+	}
+	p.mu.Unlock()
+	<-p.slots
+
+	if !keep {
+		pc.close()
+	}
+}
+
+// drain closes every idle context and makes contexts still in use close
+// when they are returned.
+func (p *contextPool) drain() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	for _, pc := range idle {
+		pc.close()
+	}
+	if len(idle) > 0 {
+		logger().Debug("Closed pooled browser contexts", "count", len(idle))
+	}
+}
+
+func (pc *pooledContext) close() {
+	if err := pc.context.Close(); err != nil {
+		logger().Warn("Failed to close browser context", "error", err)
+	}
+}
@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// The extraction modes ParseOptions.ExtractMode selects between.
+const (
+	ExtractSelectors   = "selectors"
+	ExtractReadability = "readability"
+)
+
+// ReadabilitySelector is reported as the matched selector when the content
+// was found by ExtractReadability rather than by a selector.
+const ReadabilitySelector = "readability"
+
+// ValidExtractMode reports whether mode is an extraction mode
+// ParseOptions.ExtractMode accepts; empty means ExtractSelectors.
+func ValidExtractMode(mode string) bool {
+	return mode == "" || mode == ExtractSelectors || mode == ExtractReadability
+}
+
+var (
+	// unlikelyCandidate matches the class and id of page furniture, which
+	// is dropped before scoring unless it also matches maybeCandidate.
+	unlikelyCandidate = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|header|menu|modal|nav|pager|pagination|popup|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe`)
+	maybeCandidate    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+
+	// positiveClass and negativeClass adjust the score of elements whose
+	// class or id suggests content or clutter.
+	positiveClass = regexp.MustCompile(`(?i)article|blog|body|content|entry|hentry|main|page|post|story|text`)
+	negativeClass = regexp.MustCompile(`(?i)-ad-|banner|combx|comment|contact|footer|masthead|media|meta|promo|related|scroll|share|sidebar|sponsor|tags|widget`)
+)
+
+// readabilityScored are the elements whose text is scored as a paragraph,
+// besides divs holding only inline content.
+var readabilityScored = map[string]bool{"p": true, "pre": true, "td": true, "blockquote": true}
+
+// readabilityBlocks are the elements that keep a div from being scored as
+// a paragraph itself.
+var readabilityBlocks = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"blockquote": true, "pre": true, "table": true, "ul": true, "ol": true,
+	"dl": true, "figure": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "form": true, "header": true, "footer": true,
+}
+
+// readabilityIgnored are elements that never hold readable content. The
+// rendered DOM keeps <noscript> as raw markup, which would otherwise be
+// read as text.
+var readabilityIgnored = []string{"script", "style", "noscript", "template", "svg", "iframe"}
+
+// readabilityMinParagraph is the shortest text, in bytes, scored as a
+// paragraph.
+const readabilityMinParagraph = 25
+
+// readabilityContent finds the main content of doc the way Mozilla's
+// Readability does, without relying on its markup: every paragraph earns
+// its parent a score for its length and commas, and half that for its
+// grandparent; scores are then weighed by how little of an element's text
+// is link text, and the best element is the content. Siblings scoring
+// close to it, or holding link-free paragraphs, are kept with it, since
+// articles are often split across several containers. It returns the
+// text and ReadabilitySelector, or two empty strings when no paragraph was
+// found.
+func readabilityContent(doc *html.Node, opts ParseOptions) (string, string) {
+	root := querySelector(doc, "body")
+	if root == nil {
+		root = doc
+	}
+	removed := removedNodes(root, opts)
+	for _, tag := range readabilityIgnored {
+		for _, node := range querySelectorAll(root, tag) {
+			removed[node] = true
+		}
+	}
+	removeUnlikely(root, removed)
+
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+	visitContent(root, removed, func(n *html.Node) {
+		if !readabilityScored[n.Data] && (n.Data != "div" || hasBlockChild(n)) {
+			return
+		}
+		text := collapseSpace(textContent(n, removed))
+		if len(text) < readabilityMinParagraph {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		addScore(n.Parent, score)
+		if n.Parent != nil {
+			addScore(n.Parent.Parent, score/2)
+		}
+	})
+
+	var top *html.Node
+	for _, n := range candidates {
+		scores[n] *= 1 - linkDensity(n, removed)
+		if top == nil || scores[n] > scores[top] {
+			top = n
+		}
+	}
+	if top == nil {
+		return "", ""
+	}
+
+	parts := []*html.Node{top}
+	if top.Parent != nil && top != root {
+		parts = parts[:0]
+		threshold := max(10, scores[top]*0.2)
+		for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+			if sibling.Type != html.ElementNode || removed[sibling] {
+				continue
+			}
+			if sibling == top || keepSibling(sibling, scores, threshold, removed) {
+				parts = append(parts, sibling)
+			}
+		}
+	}
+
+	var blocks []string
+	for _, part := range parts {
+		if text := renderContent(part, removed, opts); text != "" {
+			blocks = append(blocks, text)
+		}
+	}
+	sep := " "
+	if opts.PreserveStructure {
+		sep = "\n\n"
+	}
+	return strings.Join(blocks, sep), ReadabilitySelector
+}
+
+// removeUnlikely adds to removed the elements below root whose class or id
+// marks them as page furniture.
+func removeUnlikely(root *html.Node, removed map[*html.Node]bool) {
+	visitContent(root, removed, func(n *html.Node) {
+		if n.Data == "body" || n.Data == "article" || n.Data == "main" {
+			return
+		}
+		if names := classAndID(n); unlikelyCandidate.MatchString(names) && !maybeCandidate.MatchString(names) {
+			removed[n] = true
+		}
+	})
+}
+
+// visitContent calls visit for every element below root, parents first,
+// skipping removed elements and everything in them. Elements that visit
+// removes are skipped too.
+func visitContent(root *html.Node, removed map[*html.Node]bool, visit func(*html.Node)) {
+	for child := root.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || removed[child] {
+			continue
+		}
+		visit(child)
+		if !removed[child] {
+			visitContent(child, removed, visit)
+		}
+	}
+}
+
+// initialScore is the score an element starts from, by its tag and by
+// whether its class and id suggest content or clutter.
+func initialScore(n *html.Node) float64 {
+	var score float64
+	switch n.Data {
+	case "div", "article", "main", "section":
+		score = 5
+	case "pre", "td", "blockquote":
+		score = 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score = -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score = -5
+	}
+	names := classAndID(n)
+	if negativeClass.MatchString(names) {
+		score -= 25
+	}
+	if positiveClass.MatchString(names) {
+		score += 25
+	}
+	return score
+}
+
+// keepSibling reports whether sibling of the top candidate belongs to the
+// content too: it scored threshold or more, or it is a paragraph of real
+// prose with few links.
+func keepSibling(sibling *html.Node, scores map[*html.Node]float64, threshold float64, removed map[*html.Node]bool) bool {
+	if score, ok := scores[sibling]; ok && score >= threshold {
+		return true
+	}
+	if sibling.Data != "p" {
+		return false
+	}
+	text := collapseSpace(textContent(sibling, removed))
+	density := linkDensity(sibling, removed)
+	return (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.Contains(text, ". "))
+}
+
+// hasBlockChild reports whether n has a block-level child element.
+func hasBlockChild(n *html.Node) bool {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && readabilityBlocks[child.Data] {
+			return true
+		}
+	}
+	return false
+}
+
+// linkDensity returns the share of n's text that is link text.
+func linkDensity(n *html.Node, removed map[*html.Node]bool) float64 {
+	total := len(collapseSpace(textContent(n, removed)))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	visitContent(n, removed, func(child *html.Node) {
+		if child.Data == "a" {
+			links += len(collapseSpace(textContent(child, removed)))
+		}
+	})
+	return math.Min(float64(links)/float64(total), 1)
+}
+
+// classAndID returns n's class and id attributes, space separated.
+func classAndID(n *html.Node) string {
+	class, _ := attrValue(n, "class")
+	id, _ := attrValue(n, "id")
+	return class + " " + id
+}
@@ -0,0 +1,39 @@
+package parser
+
+import "strings"
+
+// RobotsMeta holds the directives of a page's robots meta tags.
+type RobotsMeta struct {
+	// NoIndex asks crawlers not to index the page, NoFollow not to follow
+	// its links.
+	NoIndex  bool
+	NoFollow bool
+}
+
+// ParseRobotsMeta reads the comma-separated directives of a robots meta
+// tag's content, such as "noindex, nofollow". "none" means both.
+func ParseRobotsMeta(content string) RobotsMeta {
+	var meta RobotsMeta
+	for _, directive := range strings.Split(content, ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "noindex":
+			meta.NoIndex = true
+		case "nofollow":
+			meta.NoFollow = true
+		case "none":
+			meta.NoIndex, meta.NoFollow = true, true
+		}
+	}
+	return meta
+}
+
+// isNoFollow reports whether a link's rel attribute asks crawlers not to
+// follow it.
+func isNoFollow(rel string) bool {
+	for _, value := range strings.Fields(strings.ToLower(rel)) {
+		if value == "nofollow" || value == "ugc" || value == "sponsored" {
+			return true
+		}
+	}
+	return false
+}
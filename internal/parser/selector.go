@@ -0,0 +1,408 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// selector is a compiled CSS selector list for the static fetcher. It
+// supports the subset the extraction selectors use: type, #id, .class and
+// attribute selectors ([a], [a=v], [a~=v], [a|=v], [a^=v], [a$=v], [a*=v],
+// optionally with the i flag) joined by descendant and child combinators.
+// Pseudo-classes are not supported.
+type selector [][]selectorStep
+
+// selectorStep is one compound selector of a complex selector; child is set
+// when it is joined to the previous step by ">".
+type selectorStep struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrMatcher
+	child   bool
+}
+
+type attrMatcher struct {
+	name  string
+	op    string
+	value string
+	fold  bool
+}
+
+// selectorCache holds compiled selectors, and the error for invalid ones,
+// keyed by their text.
+var selectorCache sync.Map
+
+type cachedSelector struct {
+	sel selector
+	err error
+}
+
+// compiled returns the compiled form of text. An invalid selector is
+// reported once and then matches nothing.
+func compiled(text string) selector {
+	if value, ok := selectorCache.Load(text); ok {
+		return value.(cachedSelector).sel
+	}
+	sel, err := compileSelector(text)
+	if _, loaded := selectorCache.LoadOrStore(text, cachedSelector{sel, err}); !loaded && err != nil {
+		logger().Warn("Invalid selector ignored by the static fetcher", "error", err)
+	}
+	return sel
+}
+
+// ValidateSelector returns the error for a selector the static fetcher
+// can't match, which it would otherwise log once and ignore.
+func ValidateSelector(text string) error {
+	_, err := compileSelector(text)
+	return err
+}
+
+func compileSelector(text string) (selector, error) {
+	var sel selector
+	var steps []selectorStep
+	child := false
+	for i := 0; ; {
+		for i < len(text) && isSelectorSpace(text[i]) {
+			i++
+		}
+		if i == len(text) || text[i] == ',' {
+			if len(steps) == 0 || child {
+				return nil, fmt.Errorf("invalid selector %q", text)
+			}
+			sel = append(sel, steps)
+			steps = nil
+			if i == len(text) {
+				return sel, nil
+			}
+			i++
+			continue
+		}
+		if text[i] == '>' {
+			if len(steps) == 0 || child {
+				return nil, fmt.Errorf("invalid selector %q", text)
+			}
+			child = true
+			i++
+			continue
+		}
+
+		end := compoundEnd(text, i)
+		step, err := parseCompound(text[i:end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %v", text, err)
+		}
+		step.child = child
+		steps = append(steps, step)
+		child = false
+		i = end
+	}
+}
+
+// compoundEnd returns where the compound selector starting at i ends: at
+// the next combinator or comma outside an attribute selector.
+func compoundEnd(text string, i int) int {
+	var quote byte
+	inAttr := false
+	for ; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case inAttr:
+			if ch == '"' || ch == '\'' {
+				quote = ch
+			} else if ch == ']' {
+				inAttr = false
+			}
+		case ch == '[':
+			inAttr = true
+		case isSelectorSpace(ch) || ch == '>' || ch == ',':
+			return i
+		}
+	}
+	return i
+}
+
+func parseCompound(text string) (selectorStep, error) {
+	var step selectorStep
+	i := 0
+	if strings.HasPrefix(text, "*") {
+		i++
+	} else {
+		end := identEnd(text, 0)
+		step.tag = strings.ToLower(text[:end])
+		i = end
+	}
+
+	for i < len(text) {
+		switch text[i] {
+		case '#', '.':
+			end := identEnd(text, i+1)
+			if end == i+1 {
+				return selectorStep{}, fmt.Errorf("missing name after %q", text[i])
+			}
+			if text[i] == '#' {
+				step.id = text[i+1 : end]
+			} else {
+				step.classes = append(step.classes, text[i+1:end])
+			}
+			i = end
+		case '[':
+			end := attrEnd(text, i+1)
+			if end < 0 {
+				return selectorStep{}, fmt.Errorf("unterminated attribute selector")
+			}
+			attr, err := parseAttr(text[i+1 : end])
+			if err != nil {
+				return selectorStep{}, err
+			}
+			step.attrs = append(step.attrs, attr)
+			i = end + 1
+		default:
+			return selectorStep{}, fmt.Errorf("unsupported %q", text[i:])
+		}
+	}
+	return step, nil
+}
+
+// attrEnd returns the index of the "]" closing the attribute selector whose
+// body starts at i, or -1.
+func attrEnd(text string, i int) int {
+	var quote byte
+	for ; i < len(text); i++ {
+		switch ch := text[i]; {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+func parseAttr(body string) (attrMatcher, error) {
+	body = strings.TrimSpace(body)
+	at := strings.IndexAny(body, "=~|^$*")
+	if at < 0 {
+		if body == "" || identEnd(body, 0) != len(body) {
+			return attrMatcher{}, fmt.Errorf("invalid attribute selector [%s]", body)
+		}
+		return attrMatcher{name: strings.ToLower(body)}, nil
+	}
+
+	attr := attrMatcher{name: strings.ToLower(strings.TrimSpace(body[:at]))}
+	rest := body[at:]
+	switch {
+	case rest[0] == '=':
+		attr.op, rest = "=", rest[1:]
+	case len(rest) > 1 && rest[1] == '=':
+		attr.op, rest = rest[:2], rest[2:]
+	default:
+		return attrMatcher{}, fmt.Errorf("invalid attribute selector [%s]", body)
+	}
+	if attr.name == "" || identEnd(attr.name, 0) != len(attr.name) {
+		return attrMatcher{}, fmt.Errorf("invalid attribute selector [%s]", body)
+	}
+
+	rest = strings.TrimSpace(rest)
+	if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+		end := strings.IndexByte(rest[1:], rest[0])
+		if end < 0 {
+			return attrMatcher{}, fmt.Errorf("invalid attribute selector [%s]", body)
+		}
+		attr.value, rest = rest[1:1+end], rest[end+2:]
+	} else {
+		end := identEnd(rest, 0)
+		attr.value, rest = rest[:end], rest[end:]
+	}
+
+	switch strings.TrimSpace(rest) {
+	case "", "s", "S":
+	case "i", "I":
+		attr.fold = true
+	default:
+		return attrMatcher{}, fmt.Errorf("invalid attribute selector [%s]", body)
+	}
+	return attr, nil
+}
+
+func identEnd(text string, i int) int {
+	for ; i < len(text); i++ {
+		ch := text[i]
+		if !(ch == '-' || ch == '_' || ch >= 0x80 ||
+			'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9') {
+			break
+		}
+	}
+	return i
+}
+
+func isSelectorSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f'
+}
+
+// matches reports whether n matches any selector in the list.
+func (s selector) matches(n *html.Node) bool {
+	for _, steps := range s {
+		if matchSteps(steps, len(steps)-1, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSteps reports whether n matches steps[i], with its ancestors
+// matching the steps before it.
+func matchSteps(steps []selectorStep, i int, n *html.Node) bool {
+	if !steps[i].matches(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if steps[i].child {
+		return n.Parent != nil && matchSteps(steps, i-1, n.Parent)
+	}
+	for ancestor := n.Parent; ancestor != nil; ancestor = ancestor.Parent {
+		if matchSteps(steps, i-1, ancestor) {
+			return true
+		}
+	}
+	return false
+}
+
+func (step selectorStep) matches(n *html.Node) bool {
+	if n.Type != html.ElementNode || (step.tag != "" && n.Data != step.tag) {
+		return false
+	}
+	if step.id != "" {
+		if id, _ := attrValue(n, "id"); id != step.id {
+			return false
+		}
+	}
+	if len(step.classes) > 0 {
+		class, _ := attrValue(n, "class")
+		classes := strings.Fields(class)
+		for _, want := range step.classes {
+			if !slices.Contains(classes, want) {
+				return false
+			}
+		}
+	}
+	for _, attr := range step.attrs {
+		if !attr.matches(n) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m attrMatcher) matches(n *html.Node) bool {
+	value, ok := attrValue(n, m.name)
+	if !ok {
+		return false
+	}
+	want := m.value
+	if m.fold {
+		value, want = strings.ToLower(value), strings.ToLower(want)
+	}
+	switch m.op {
+	case "":
+		return true
+	case "=":
+		return value == want
+	case "~=":
+		return slices.Contains(strings.Fields(value), want)
+	case "|=":
+		return value == want || strings.HasPrefix(value, want+"-")
+	case "^=":
+		return want != "" && strings.HasPrefix(value, want)
+	case "$=":
+		return want != "" && strings.HasSuffix(value, want)
+	case "*=":
+		return want != "" && strings.Contains(value, want)
+	}
+	return false
+}
+
+// attrValue returns n's attribute name and whether it is set.
+func attrValue(n *html.Node, name string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Namespace == "" && attr.Key == name {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
+
+// querySelector returns the first element below root, in document order,
+// matching the selector text, or nil.
+func querySelector(root *html.Node, text string) *html.Node {
+	sel := compiled(text)
+	if sel == nil {
+		return nil
+	}
+	var found *html.Node
+	walkElements(root, func(n *html.Node) bool {
+		if sel.matches(n) {
+			found = n
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// querySelectorAll returns every element below root matching the selector
+// text, in document order.
+func querySelectorAll(root *html.Node, text string) []*html.Node {
+	sel := compiled(text)
+	if sel == nil {
+		return nil
+	}
+	var found []*html.Node
+	walkElements(root, func(n *html.Node) bool {
+		if sel.matches(n) {
+			found = append(found, n)
+		}
+		return true
+	})
+	return found
+}
+
+// closest returns n or its nearest ancestor matching the selector text, or
+// nil.
+func closest(n *html.Node, text string) *html.Node {
+	sel := compiled(text)
+	for ; n != nil && sel != nil; n = n.Parent {
+		if n.Type == html.ElementNode && sel.matches(n) {
+			return n
+		}
+	}
+	return nil
+}
+
+// walkElements calls visit for each element below root in document order
+// until it returns false.
+func walkElements(root *html.Node, visit func(*html.Node) bool) bool {
+	for child := root.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && !visit(child) {
+			return false
+		}
+		if !walkElements(child, visit) {
+			return false
+		}
+	}
+	return true
+}
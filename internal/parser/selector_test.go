@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const selectorDoc = `<html><body>
+<div id="page" class="layout wide">
+	<main id="main">
+		<article id="post" class="post entry" data-kind="blog-post" lang="en-US">
+			<p id="intro" class="lead">Intro</p>
+			<section id="body"><p id="deep">Deep</p></section>
+		</article>
+	</main>
+	<nav id="nav" class="menu" aria-label="Main Menu"><a id="home" href="/">Home</a></nav>
+</div>
+<footer id="footer" class="site-footer"></footer>
+</body></html>`
+
+func TestSelectorMatches(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"article", []string{"post"}},
+		{"ARTICLE", []string{"post"}},
+		{"#intro", []string{"intro"}},
+		{".post", []string{"post"}},
+		{".post.entry", []string{"post"}},
+		{".post.missing", nil},
+		{"div.layout.wide", []string{"page"}},
+		{"main p", []string{"intro", "deep"}},
+		{"article > p", []string{"intro"}},
+		{"main > p", nil},
+		{"div > main > article", []string{"post"}},
+		{"nav, footer", []string{"nav", "footer"}},
+		{"*#footer", []string{"footer"}},
+		{"[data-kind]", []string{"post"}},
+		{`[data-kind="blog-post"]`, []string{"post"}},
+		{"[data-kind=blog]", nil},
+		{"[class~=entry]", []string{"post"}},
+		{"[class~=ent]", nil},
+		{"[lang|=en]", []string{"post"}},
+		{"[data-kind^=blog]", []string{"post"}},
+		{"[data-kind$=post]", []string{"post"}},
+		{"[data-kind*=g-p]", []string{"post"}},
+		{"[data-kind^='']", nil},
+		{`[aria-label="main menu" i]`, []string{"nav"}},
+		{`[aria-label="main menu"]`, nil},
+		{`[href="/"]`, []string{"home"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, n := range querySelectorAll(doc, tt.selector) {
+			id, _ := attrValue(n, "id")
+			got = append(got, id)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("querySelectorAll(%q) = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		valid    bool
+	}{
+		{"article", true},
+		{"main article, .post-content", true},
+		{"div > p", true},
+		{`a[href$=".pdf" i]`, true},
+		{"[role='main']", true},
+		{"", false},
+		{"div >", false},
+		{"> div", false},
+		{"div,", false},
+		{"div > > p", false},
+		{".", false},
+		{"#", false},
+		{"p:first-child", false},
+		{"a::before", false},
+		{"div + p", false},
+		{"[href", false},
+		{"[=x]", false},
+		{"[href!=x]", false},
+		{`[href="x]`, false},
+		{"[href=x q]", false},
+	}
+	for _, tt := range tests {
+		err := ValidateSelector(tt.selector)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateSelector(%q) = %v, want valid %v", tt.selector, err, tt.valid)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deep := querySelector(doc, "#deep")
+	if deep == nil {
+		t.Fatal("#deep not found")
+	}
+	for selector, want := range map[string]string{"article": "post", "p": "deep", "main": "main", "nav": ""} {
+		var got string
+		if n := closest(deep, selector); n != nil {
+			got, _ = attrValue(n, "id")
+		}
+		if got != want {
+			t.Errorf("closest(#deep, %q) = %q, want %q", selector, got, want)
+		}
+	}
+}
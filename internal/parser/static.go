@@ -0,0 +1,603 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// maxStaticBodySize caps how much of a page the static fetcher reads.
+const maxStaticBodySize = 10 << 20
+
+// StaticFetcher fetches pages with a plain HTTP GET and extracts them from
+// the HTML as served, without running scripts. It is far lighter than the
+// browser but only suits sites that don't render their content with
+// JavaScript. ExtractScript is not supported, and the browser-only options
+// (device emulation, HashRouting) are ignored.
+type StaticFetcher struct {
+	client *http.Client
+}
+
+// NewStaticFetcher returns a StaticFetcher that requests pages with client,
+// or with a client with a 30 second timeout when client is nil.
+func NewStaticFetcher(client *http.Client) *StaticFetcher {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &StaticFetcher{client: client}
+}
+
+// Fetch requests url and extracts it the way ParseWithPlaywright would from
+// the page with scripting disabled.
+func (f *StaticFetcher) Fetch(ctx context.Context, url string, opts ParseOptions) (ParseResult, error) {
+	if opts.ExtractScript != "" {
+		return ParseResult{}, fmt.Errorf("custom extract scripts need the browser")
+	}
+
+	logger().Debug("Fetching URL", "url", url)
+	doc, response, err := f.get(ctx, url, opts)
+	if err != nil {
+		return ParseResult{Response: response}, err
+	}
+	base := documentBase(doc, response.URL)
+
+	var ampURL string
+	if opts.PreferAMP {
+		if link := querySelector(doc, `link[rel="amphtml"][href]`); link != nil {
+			href, _ := attrValue(link, "href")
+			if amp, err := base.Parse(strings.TrimSpace(href)); err == nil && amp.String() != url {
+				logger().Debug("Fetching AMP version", "url", amp)
+				ampOpts := opts
+				ampOpts.CheckResponse = nil
+				ampDoc, ampResponse, err := f.get(ctx, amp.String(), ampOpts)
+				if err == nil && ampResponse.StatusCode == http.StatusOK {
+					ampURL = amp.String()
+					doc, base = ampDoc, documentBase(ampDoc, ampResponse.URL)
+				} else {
+					logger().Warn("Failed to load AMP version, using the original", "amp_url", amp, "url", url, "error", err)
+				}
+			}
+		}
+	}
+
+	published := staticPublished(doc)
+	description, canonical, robots := staticMeta(doc, base)
+	var declaredLang string
+	if node := querySelector(doc, "html[lang]"); node != nil {
+		declaredLang, _ = attrValue(node, "lang")
+	}
+
+	contentSelectors := opts.ContentSelectors
+	if contentSelectors == nil {
+		contentSelectors = defaultContentSelectors
+	}
+	var contentStr, matchedSelector string
+	if opts.ExtractMode == ExtractReadability {
+		contentStr, matchedSelector = readabilityContent(doc, opts)
+	}
+	if contentStr == "" {
+		contentStr, matchedSelector = staticContent(doc, contentSelectors, opts)
+	}
+
+	usedFallback := false
+	if len(contentStr) < opts.MinContentLength || contentStr == "" {
+		if fallback, selector := staticFallback(doc, contentStr, opts); selector != "" {
+			usedFallback = true
+			contentStr, matchedSelector = fallback, selector
+		}
+	}
+
+	if opts.NoscriptFallback && (contentStr == "" || len(contentStr) < opts.MinContentLength) {
+		if noscript := staticNoscript(doc); len(noscript) > len(contentStr) {
+			logger().Debug("Using <noscript> content instead of extracted content", "noscript_chars", len(noscript), "extracted_chars", len(contentStr))
+			usedFallback = true
+			contentStr, matchedSelector = noscript, NoscriptSelector
+		}
+	}
+
+	var comments string
+	if opts.IncludeComments {
+		comments = staticComments(doc)
+	}
+
+	linksList := staticLinks(doc, base)
+	logger().Debug("Extracted content", "bytes", len(contentStr), "links", len(linksList))
+
+	var title string
+	if node := querySelector(doc, "title"); node != nil {
+		title = collapseSpace(textContent(node, nil))
+	}
+
+	return ParseResult{
+		Title:      title,
+		Text:       contentStr,
+		Links:      linksList,
+		Response:   response,
+		Confidence: extractionConfidence(contentStr, len(linksList), usedFallback, matchedSelector == BodySelector),
+
+		MatchedSelector: matchedSelector,
+		Comments:        comments,
+		AMPURL:          ampURL,
+		Published:       published,
+		Description:     description,
+		Canonical:       canonical,
+		RobotsMeta:      robots,
+		Lang:            pageLang(declaredLang, contentStr, opts.DetectLang),
+	}, nil
+}
+
+// get requests pageURL, checks the response with opts.CheckResponse and
+// parses the body. The returned Response is filled in whenever one was
+// received.
+func (f *StaticFetcher) get(ctx context.Context, pageURL string, opts ParseOptions) (*html.Node, Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, Response{}, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	req.Header.Set("Accept", acceptHeader)
+	req.Header.Set("Accept-Language", acceptLanguageHeader)
+	if opts.UserAgent != "" {
+		req.Header.Set("User-Agent", opts.UserAgent)
+	}
+	for name, value := range opts.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, value := range opts.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+
+	client := f.client
+	if opts.Transport != nil {
+		withTransport := *client
+		withTransport.Transport = opts.Transport
+		client = &withTransport
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, Response{}, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	response := describeHTTPResponse(resp)
+	logger().Debug("Response received", "url", pageURL, "status", response.Status, "content_type", response.ContentType, "final_url", response.URL)
+	if opts.CheckResponse != nil {
+		if err := opts.CheckResponse(response); err != nil {
+			return nil, response, err
+		}
+	}
+
+	if opts.MaxContentBytes > 0 && response.ContentLength > opts.MaxContentBytes {
+		return nil, response, fmt.Errorf("%w: Content-Length %d, limit %d", ErrContentTooLarge, response.ContentLength, opts.MaxContentBytes)
+	}
+	limit := int64(maxStaticBodySize)
+	if opts.MaxContentBytes > 0 {
+		// Read one byte more than allowed to tell a body of exactly the
+		// limit from a longer one.
+		limit = opts.MaxContentBytes + 1
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, response, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if opts.MaxContentBytes > 0 && int64(len(body)) > opts.MaxContentBytes {
+		return nil, response, fmt.Errorf("%w: more than %d bytes", ErrContentTooLarge, opts.MaxContentBytes)
+	}
+	response.BodySize = int64(len(body))
+	text, _, err := DecodeHTML(body, response.ContentType, opts.Charset)
+	if err != nil {
+		return nil, response, err
+	}
+	// Parse as a browser with scripting disabled does, so that <noscript>
+	// content is part of the document.
+	doc, err := html.ParseWithOptions(strings.NewReader(text), html.ParseOptionEnableScripting(false))
+	if err != nil {
+		return nil, response, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+	return doc, response, nil
+}
+
+// describeHTTPResponse is describeResponse for a response received over
+// plain HTTP.
+func describeHTTPResponse(resp *http.Response) Response {
+	response := Response{
+		StatusCode:    resp.StatusCode,
+		Status:        resp.Status,
+		ContentType:   resp.Header.Get("Content-Type"),
+		URL:           resp.Request.URL.String(),
+		RetryAfter:    resp.Header.Get("Retry-After"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+		ContentLength: resp.ContentLength,
+	}
+	for req := resp.Request; req.Response != nil; req = req.Response.Request {
+		response.Redirects++
+	}
+	return response
+}
+
+// documentBase returns the URL doc's relative links resolve against: its
+// <base href> when it has one, else pageURL.
+func documentBase(doc *html.Node, pageURL string) *url.URL {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		base = &url.URL{}
+	}
+	if node := querySelector(doc, "base[href]"); node != nil {
+		href, _ := attrValue(node, "href")
+		if resolved, err := base.Parse(strings.TrimSpace(href)); err == nil {
+			return resolved
+		}
+	}
+	return base
+}
+
+var skipToContent = regexp.MustCompile(`(?i)Skip to (?:main )?content`)
+
+// staticContent extracts the text of the first element matching one of
+// selectors, without the elements matching the remove selectors, and
+// returns it with that selector.
+func staticContent(doc *html.Node, selectors []string, opts ParseOptions) (string, string) {
+	var content *html.Node
+	var matched string
+	for _, selector := range selectors {
+		if content = querySelector(doc, selector); content != nil {
+			matched = selector
+			break
+		}
+	}
+	if content == nil {
+		return "", ""
+	}
+	return renderContent(content, removedNodes(content, opts), opts), matched
+}
+
+// removedNodes returns the elements below root that the remove selectors
+// (and, unless IncludeComments is set, the comment selectors) strip from
+// the content.
+func removedNodes(root *html.Node, opts ParseOptions) map[*html.Node]bool {
+	removeSelectors := opts.RemoveSelectors
+	if removeSelectors == nil {
+		removeSelectors = defaultRemoveSelectors
+	}
+	if !opts.IncludeComments {
+		removeSelectors = append(removeSelectors[:len(removeSelectors):len(removeSelectors)], ".comments", ".comment-section")
+	}
+	removed := make(map[*html.Node]bool)
+	for _, selector := range removeSelectors {
+		for _, node := range querySelectorAll(root, selector) {
+			removed[node] = true
+		}
+	}
+	return removed
+}
+
+// renderContent returns the text of content without the removed elements,
+// as Markdown with PreserveStructure.
+func renderContent(content *html.Node, removed map[*html.Node]bool, opts ParseOptions) string {
+	if opts.PreserveStructure {
+		return toMarkdown(content, removed)
+	}
+	text := skipToContent.ReplaceAllString(collapseSpace(textContent(content, removed)), "")
+	return strings.TrimSpace(text)
+}
+
+// staticFallback is extractWithFallback for the static fetcher.
+func staticFallback(doc *html.Node, content string, opts ParseOptions) (string, string) {
+	fallbacks := opts.FallbackSelectors
+	if fallbacks == nil {
+		fallbacks = defaultFallbackSelectors
+	}
+
+	for _, selector := range fallbacks {
+		logger().Debug("Extracted little content, retrying with fallback selector", "chars", len(content), "selector", selector)
+		text, _ := staticContent(doc, []string{selector}, opts)
+		if text != "" && len(text) >= opts.MinContentLength {
+			return text, selector
+		}
+	}
+	return content, ""
+}
+
+// markdownBlockTags are the elements toMarkdown starts a new paragraph
+// for, besides headings and lists.
+var markdownBlockTags = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"blockquote": true, "table": true, "tr": true, "figure": true,
+	"figcaption": true, "dl": true, "dt": true, "dd": true, "br": true, "hr": true,
+}
+
+// toMarkdown renders root like the browser's PreserveStructure extraction:
+// headings as Markdown headings, list items as (nested) bullets and every
+// other block as a paragraph.
+func toMarkdown(root *html.Node, removed map[*html.Node]bool) string {
+	type block struct {
+		text string
+		list bool
+	}
+	var blocks []block
+	var inline strings.Builder
+	prefix, listItem := "", false
+
+	flush := func() {
+		if text := collapseSpace(inline.String()); text != "" {
+			blocks = append(blocks, block{text: prefix + text, list: listItem})
+		}
+		inline.Reset()
+		prefix, listItem = "", false
+	}
+
+	var walk func(n *html.Node, listDepth int)
+	walkChildren := func(n *html.Node, listDepth int) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child, listDepth)
+		}
+	}
+	walk = func(n *html.Node, listDepth int) {
+		if removed[n] {
+			return
+		}
+		if n.Type == html.TextNode {
+			inline.WriteString(n.Data)
+			return
+		}
+		if n.Type != html.ElementNode {
+			return
+		}
+
+		switch tag := n.Data; {
+		case len(tag) == 2 && tag[0] == 'h' && '1' <= tag[1] && tag[1] <= '6':
+			flush()
+			inline.WriteString(textContent(n, removed))
+			prefix = strings.Repeat("#", int(tag[1]-'0')) + " "
+			flush()
+		case tag == "ul" || tag == "ol":
+			flush()
+			walkChildren(n, listDepth+1)
+			flush()
+		case tag == "li":
+			flush()
+			prefix = strings.Repeat("  ", max(listDepth-1, 0)) + "- "
+			listItem = true
+			walkChildren(n, listDepth)
+			flush()
+		case markdownBlockTags[tag]:
+			flush()
+			walkChildren(n, listDepth)
+			flush()
+		default:
+			walkChildren(n, listDepth)
+		}
+	}
+	walk(root, 0)
+	flush()
+
+	// Consecutive list items stay on adjacent lines; everything else is
+	// separated by a blank line.
+	var out strings.Builder
+	for i, b := range blocks {
+		if i > 0 {
+			if b.list && blocks[i-1].list {
+				out.WriteString("\n")
+			} else {
+				out.WriteString("\n\n")
+			}
+		}
+		out.WriteString(b.text)
+	}
+	return out.String()
+}
+
+// staticNoscript returns the text of the page's <noscript> elements, one
+// paragraph each.
+func staticNoscript(doc *html.Node) string {
+	var parts []string
+	for _, noscript := range querySelectorAll(doc, "noscript") {
+		removed := make(map[*html.Node]bool)
+		for _, node := range querySelectorAll(noscript, "script, style") {
+			removed[node] = true
+		}
+		var lines []string
+		for _, line := range strings.Split(textContent(noscript, removed), "\n") {
+			if line = collapseSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 0 {
+			parts = append(parts, strings.Join(lines, "\n"))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// staticComments is extractComments for the static fetcher.
+func staticComments(doc *html.Node) string {
+	var parts []string
+	for _, section := range outermost(querySelectorAll(doc, `#comments, .comments, .comment-section, [data-testid="comments"]`)) {
+		comments := outermost(querySelectorAll(section, ".comment-body, .comment-content, .comment, article, li"))
+		if len(comments) == 0 {
+			if text := collapseSpace(textContent(section, nil)); text != "" {
+				parts = append(parts, text)
+			}
+			continue
+		}
+		var texts []string
+		for _, comment := range comments {
+			if text := collapseSpace(textContent(comment, nil)); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		if len(texts) > 0 {
+			parts = append(parts, strings.Join(texts, "\n\n"))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// chromeSelector matches the site chrome links are tagged as navigation in.
+const chromeSelector = `nav, header, footer, aside, [role="navigation"], [role="banner"], [role="contentinfo"], .menu, .nav, .navbar, .sidebar, .breadcrumb`
+
+// staticLinks is extractLinks for the static fetcher, resolving links
+// against base.
+func staticLinks(doc *html.Node, base *url.URL) []Link {
+	var root *html.Node
+	for _, selector := range contentRootSelectors {
+		if root = querySelector(doc, selector); root != nil {
+			break
+		}
+	}
+
+	index := make(map[string]int)
+	var links []Link
+	for _, anchor := range querySelectorAll(doc, "a[href]") {
+		href, _ := attrValue(anchor, "href")
+		link, err := base.Parse(strings.TrimSpace(href))
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+			continue
+		}
+
+		nav := closest(anchor, chromeSelector)
+		inContent := nav == nil
+		if root != nil {
+			inContent = contains(root, anchor) && !(nav != nil && contains(root, nav))
+		}
+
+		rel, _ := attrValue(anchor, "rel")
+		noFollow := isNoFollow(rel)
+
+		i, ok := index[link.String()]
+		if !ok {
+			i = len(links)
+			index[link.String()] = i
+			links = append(links, Link{URL: link.String(), NoFollow: true})
+		}
+		links[i].NoFollow = links[i].NoFollow && noFollow
+		if inContent {
+			links[i].InContent = true
+		} else {
+			links[i].InNavigation = true
+		}
+	}
+	return links
+}
+
+// staticPublished is extractPublished for the static fetcher.
+func staticPublished(doc *html.Node) string {
+	if meta := querySelector(doc, `meta[property="article:published_time"], meta[itemprop="datePublished"], meta[name="date"], meta[name="DC.date.issued"]`); meta != nil {
+		if content, _ := attrValue(meta, "content"); content != "" {
+			return strings.TrimSpace(content)
+		}
+	}
+	for _, script := range querySelectorAll(doc, `script[type="application/ld+json"]`) {
+		var data any
+		if err := json.Unmarshal([]byte(textContent(script, nil)), &data); err != nil {
+			// Ignore malformed JSON-LD.
+			continue
+		}
+		nodes := []any{data}
+		switch data := data.(type) {
+		case []any:
+			nodes = data
+		case map[string]any:
+			if graph, ok := data["@graph"].([]any); ok {
+				nodes = append(nodes, graph...)
+			}
+		}
+		for _, node := range nodes {
+			if fields, ok := node.(map[string]any); ok {
+				if published, ok := fields["datePublished"].(string); ok && published != "" {
+					return strings.TrimSpace(published)
+				}
+			}
+		}
+	}
+	if node := querySelector(doc, `article time[datetime], time[itemprop="datePublished"]`); node != nil {
+		datetime, _ := attrValue(node, "datetime")
+		return strings.TrimSpace(datetime)
+	}
+	return ""
+}
+
+// staticMeta is extractMeta for the static fetcher.
+func staticMeta(doc *html.Node, base *url.URL) (string, string, RobotsMeta) {
+	var description, canonical string
+	meta := querySelector(doc, `meta[name="description" i][content]`)
+	if meta == nil {
+		meta = querySelector(doc, `meta[property="og:description"][content]`)
+	}
+	if meta != nil {
+		description, _ = attrValue(meta, "content")
+	}
+	if link := querySelector(doc, `link[rel~="canonical" i][href]`); link != nil {
+		href, _ := attrValue(link, "href")
+		if resolved, err := base.Parse(strings.TrimSpace(href)); err == nil {
+			canonical = resolved.String()
+		}
+	}
+	var robots []string
+	for _, node := range querySelectorAll(doc, `meta[name="robots" i][content]`) {
+		content, _ := attrValue(node, "content")
+		robots = append(robots, content)
+	}
+	return strings.TrimSpace(description), canonical, ParseRobotsMeta(strings.Join(robots, ","))
+}
+
+// textContent returns the text below n, like the DOM's textContent, leaving
+// out the elements in removed.
+func textContent(n *html.Node, removed map[*html.Node]bool) string {
+	var text strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if removed[n] {
+			return
+		}
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return text.String()
+}
+
+// outermost drops the nodes that are inside another of nodes.
+func outermost(nodes []*html.Node) []*html.Node {
+	set := make(map[*html.Node]bool, len(nodes))
+	for _, n := range nodes {
+		set[n] = true
+	}
+	var kept []*html.Node
+	for _, n := range nodes {
+		inside := false
+		for ancestor := n.Parent; ancestor != nil && !inside; ancestor = ancestor.Parent {
+			inside = set[ancestor]
+		}
+		if !inside {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+// contains reports whether n is root or below it.
+func contains(root, n *html.Node) bool {
+	for ; n != nil; n = n.Parent {
+		if n == root {
+			return true
+		}
+	}
+	return false
+}
+
+func collapseSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestStaticContent(t *testing.T) {
+	tests := []struct {
+		name         string
+		html         string
+		opts         ParseOptions
+		want         string
+		wantSelector string
+	}{
+		{
+			name: "article wins over body",
+			html: `<body><nav>Menu</nav><article><h1>Title</h1>
+<p>Story text.</p></article><footer>Footer</footer></body>`,
+			want:         "Title Story text.",
+			wantSelector: "article",
+		},
+		{
+			name:         "main when there is no article",
+			html:         `<body><header>Site</header><main><p>Main text.</p></main></body>`,
+			want:         "Main text.",
+			wantSelector: "main",
+		},
+		{
+			name:         "body as a last resort",
+			html:         `<body><div>Only text.</div></body>`,
+			want:         "Only text.",
+			wantSelector: "body",
+		},
+		{
+			name:         "default remove selectors",
+			html:         `<body><article><p>Kept.</p><script>var x;</script><aside>Aside</aside><div class="social-share">Share</div><pre>code</pre></article></body>`,
+			want:         "Kept.",
+			wantSelector: "article",
+		},
+		{
+			name:         "skip link text is dropped",
+			html:         `<body><main><a href="#c">Skip to main content</a><p>Text.</p></main></body>`,
+			want:         "Text.",
+			wantSelector: "main",
+		},
+		{
+			name:         "custom content selectors",
+			html:         `<body><article>Teaser</article><div class="story-body"><p>Story.</p></div></body>`,
+			opts:         ParseOptions{ContentSelectors: []string{".story-body", "article"}},
+			want:         "Story.",
+			wantSelector: ".story-body",
+		},
+		{
+			name: "custom remove selectors replace the defaults",
+			html: `<body><article><p>Story.</p><div class="ad">Buy now</div>
+<aside>Aside</aside></article></body>`,
+			opts:         ParseOptions{RemoveSelectors: []string{".ad"}},
+			want:         "Story. Aside",
+			wantSelector: "article",
+		},
+		{
+			name:         "comments removed unless included",
+			html:         `<body><article><p>Story.</p><div class="comments">Nice post</div></article></body>`,
+			want:         "Story.",
+			wantSelector: "article",
+		},
+		{
+			name: "comments included",
+			html: `<body><article><p>Story.</p>
+<div class="comments">Nice post</div></article></body>`,
+			opts:         ParseOptions{IncludeComments: true},
+			want:         "Story. Nice post",
+			wantSelector: "article",
+		},
+		{
+			name:         "structure preserved as markdown",
+			html:         `<body><article><h2>Heading</h2><p>First paragraph.</p><ul><li>One</li><li>Two<ul><li>Nested</li></ul></li></ul><p>Last.</p></article></body>`,
+			opts:         ParseOptions{PreserveStructure: true},
+			want:         "## Heading\n\nFirst paragraph.\n\n- One\n- Two\n  - Nested\n\nLast.",
+			wantSelector: "article",
+		},
+		{
+			name:         "no selector matches",
+			html:         `<body><p>Text.</p></body>`,
+			opts:         ParseOptions{ContentSelectors: []string{".missing"}},
+			want:         "",
+			wantSelector: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			selectors := tt.opts.ContentSelectors
+			if selectors == nil {
+				selectors = defaultContentSelectors
+			}
+			got, selector := staticContent(doc, selectors, tt.opts)
+			if got != tt.want || selector != tt.wantSelector {
+				t.Errorf("staticContent() = %q, %q; want %q, %q", got, selector, tt.want, tt.wantSelector)
+			}
+		})
+	}
+}
+
+func TestStaticFetcherFetch(t *testing.T) {
+	const page = `<!DOCTYPE html>
+<html lang="en-GB"><head>
+	<title> Example   Post </title>
+	<meta name="description" content="A short post.">
+	<meta name="robots" content="noindex">
+	<base href="/blog/">
+</head><body>
+	<nav><a href="/">Home</a></nav>
+	<article>
+		<p>Body text with a <a href="next">relative link</a>.</p>
+		<p><a href="https://other.example/x" rel="nofollow">External</a></p>
+		<p><a href="mailto:someone@example.com">Mail</a></p>
+	</article>
+	<div class="short"><p>x</p></div>
+</body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	var checked Response
+	result, err := NewStaticFetcher(nil).Fetch(context.Background(), server.URL+"/blog/post", ParseOptions{
+		CheckResponse: func(resp Response) error {
+			checked = resp
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if checked.StatusCode != http.StatusOK || !strings.HasPrefix(checked.ContentType, "text/html") {
+		t.Errorf("CheckResponse got status %d, content type %q", checked.StatusCode, checked.ContentType)
+	}
+	if result.Title != "Example Post" {
+		t.Errorf("Title = %q", result.Title)
+	}
+	if want := "Body text with a relative link. External Mail"; result.Text != want {
+		t.Errorf("Text = %q, want %q", result.Text, want)
+	}
+	if result.MatchedSelector != "article" {
+		t.Errorf("MatchedSelector = %q", result.MatchedSelector)
+	}
+	if result.Description != "A short post." || !result.RobotsMeta.NoIndex {
+		t.Errorf("Description = %q, RobotsMeta = %+v", result.Description, result.RobotsMeta)
+	}
+	if result.Lang != "en" {
+		t.Errorf("Lang = %q, want en", result.Lang)
+	}
+
+	links := make(map[string]Link)
+	for _, link := range result.Links {
+		links[link.URL] = link
+	}
+	if len(links) != 3 {
+		t.Errorf("Links = %+v, want the home, relative and external links", result.Links)
+	}
+	if link, ok := links[server.URL+"/blog/next"]; !ok || !link.InContent {
+		t.Errorf("relative link not resolved against <base> or not in content: %+v", result.Links)
+	}
+	if link, ok := links[server.URL+"/"]; !ok || !link.InNavigation || link.InContent {
+		t.Errorf("navigation link = %+v", link)
+	}
+	if link := links["https://other.example/x"]; !link.NoFollow {
+		t.Errorf("nofollow link = %+v", link)
+	}
+}
+
+func TestStaticFetcherFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<body><article>Tiny</article><div role="main"><p>A much longer main region with the real text.</p></div></body>`))
+	}))
+	defer server.Close()
+
+	result, err := NewStaticFetcher(nil).Fetch(context.Background(), server.URL, ParseOptions{MinContentLength: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.MatchedSelector != `[role="main"]` || result.Text != "A much longer main region with the real text." {
+		t.Errorf("Fetch() = %q from %q, want the [role=main] fallback", result.Text, result.MatchedSelector)
+	}
+}
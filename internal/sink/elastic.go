@@ -0,0 +1,276 @@
+package sink
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"webcrawler/internal/crawler"
+)
+
+const (
+	defaultElasticIndex  = "crawl-results"
+	elasticBatchSize     = 100
+	elasticFlushInterval = 5 * time.Second
+	// elasticMaxFailures is how many bulk requests in a row may fail
+	// before the documents they held are dropped.
+	elasticMaxFailures = 3
+)
+
+// elasticMapping is applied when the sink creates its index.
+const elasticMapping = `{
+	"mappings": {
+		"properties": {
+			"url":        {"type": "keyword"},
+			"title":      {"type": "text"},
+			"content":    {"type": "text"},
+			"summary":    {"type": "text"},
+			"crawled_at": {"type": "date"},
+			"depth":      {"type": "integer"}
+		}
+	}
+}`
+
+type elasticDocument struct {
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Summary   string    `json:"summary"`
+	CrawledAt time.Time `json:"crawled_at"`
+	Depth     int       `json:"depth"`
+}
+
+// ElasticSink bulk-indexes crawl results into an Elasticsearch or OpenSearch
+// index. Documents are buffered and flushed when the batch fills up, every
+// few seconds, and on Close.
+type ElasticSink struct {
+	endpoint string
+	index    string
+	mode     crawler.OutputMode
+	client   *http.Client
+
+	mu    sync.Mutex
+	batch []elasticDocument
+
+	// flushMu serializes bulk requests, which are sent without holding mu
+	// so that Write doesn't wait for them.
+	flushMu      sync.Mutex
+	indexChecked bool
+	failures     int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewElasticSink creates a sink writing to index at endpoint. Documents are
+// keyed by URL, so recrawled pages replace their previous document instead
+// of adding another. With OutputOverwrite an existing index is deleted and
+// recreated.
+func NewElasticSink(endpoint, index string, mode crawler.OutputMode) *ElasticSink {
+	if index == "" {
+		index = defaultElasticIndex
+	}
+	s := &ElasticSink{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		index:    index,
+		mode:     mode,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.flushPeriodically()
+	return s
+}
+
+// Write queues a successful result for indexing. Failed results are skipped.
+func (s *ElasticSink) Write(result crawler.Result) error {
+	if result.Error != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	s.batch = append(s.batch, elasticDocument{
+		URL:       result.URL,
+		Title:     result.Title,
+		Content:   result.Content,
+		Summary:   result.Summary,
+		CrawledAt: result.CrawledAt,
+		Depth:     result.Depth,
+	})
+	full := len(s.batch) >= elasticBatchSize
+	s.mu.Unlock()
+
+	if full {
+		return s.flush()
+	}
+	return nil
+}
+
+// Close stops the periodic flush and indexes any buffered documents.
+func (s *ElasticSink) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.flush()
+}
+
+func (s *ElasticSink) flushPeriodically() {
+	defer close(s.done)
+	ticker := time.NewTicker(elasticFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.flush(); err != nil {
+				slog.Error("Failed to flush results to Elasticsearch", "error", err)
+			}
+		}
+	}
+}
+
+// flush takes the buffered documents and sends them through the bulk API.
+// Documents whose request fails are put back for the next flush, until
+// elasticMaxFailures requests in a row have failed and they are dropped.
+func (s *ElasticSink) flush() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	batch := s.batch
+	s.batch = nil
+	s.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := s.send(batch)
+	if err == nil {
+		s.failures = 0
+		return nil
+	}
+	s.failures++
+	if s.failures >= elasticMaxFailures {
+		s.failures = 0
+		return fmt.Errorf("dropped %d documents after %d failed bulk requests: %v", len(batch), elasticMaxFailures, err)
+	}
+	s.mu.Lock()
+	s.batch = append(batch, s.batch...)
+	s.mu.Unlock()
+	return err
+}
+
+// send indexes batch through the bulk API, creating the index first if
+// needed. s.flushMu must be held.
+func (s *ElasticSink) send(batch []elasticDocument) error {
+	if !s.indexChecked {
+		if err := s.ensureIndex(); err != nil {
+			return err
+		}
+		s.indexChecked = true
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, doc := range batch {
+		action := map[string]map[string]string{"index": {"_index": s.index, "_id": documentID(doc.URL)}}
+		if err := encoder.Encode(action); err != nil {
+			return fmt.Errorf("failed to encode bulk action: %v", err)
+		}
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode document: %v", err)
+		}
+	}
+
+	req, err := http.NewRequest("POST", s.endpoint+"/_bulk", &body)
+	if err != nil {
+		return fmt.Errorf("failed to create bulk request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send bulk request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var bulkResp struct {
+		Errors bool `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&bulkResp); err != nil {
+		return fmt.Errorf("failed to decode bulk response: %v", err)
+	}
+	if resp.StatusCode >= 300 || bulkResp.Errors {
+		return fmt.Errorf("bulk indexing of %d documents failed (status %d)", len(batch), resp.StatusCode)
+	}
+
+	slog.Debug("Indexed documents", "count", len(batch), "index", s.index)
+	return nil
+}
+
+// documentID derives a stable document ID from a URL.
+func documentID(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureIndex creates the index with elasticMapping unless it already
+// exists. In overwrite mode an existing index is deleted first.
+func (s *ElasticSink) ensureIndex() error {
+	resp, err := s.client.Head(s.endpoint + "/" + s.index)
+	if err != nil {
+		return fmt.Errorf("failed to check index: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		if s.mode != crawler.OutputOverwrite {
+			return nil
+		}
+		if err := s.deleteIndex(); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest("PUT", s.endpoint+"/"+s.index, strings.NewReader(elasticMapping))
+	if err != nil {
+		return fmt.Errorf("failed to create index request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err = s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to create index: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to create index %s: status %d", s.index, resp.StatusCode)
+	}
+	slog.Debug("Created index", "index", s.index)
+	return nil
+}
+
+func (s *ElasticSink) deleteIndex() error {
+	req, err := http.NewRequest("DELETE", s.endpoint+"/"+s.index, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create delete request: %v", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete index: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to delete index %s: status %d", s.index, resp.StatusCode)
+	}
+	slog.Debug("Deleted previous index", "index", s.index)
+	return nil
+}
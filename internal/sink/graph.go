@@ -0,0 +1,128 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"webcrawler/internal/crawler"
+)
+
+// GraphSink collects the links between crawled pages and writes them on
+// Close, as a Graphviz DOT file when the path ends in .dot or .gv and as a
+// JSON adjacency list (page URL to the URLs it links to) otherwise. Every
+// crawled page is a node, even one with no links, so that orphans show.
+type GraphSink struct {
+	path string
+	dot  bool
+
+	mu    sync.Mutex
+	edges map[string]map[string]bool
+}
+
+// NewGraphSink creates a sink writing the link graph to path, replacing
+// any earlier graph.
+func NewGraphSink(path string) *GraphSink {
+	ext := strings.ToLower(filepath.Ext(path))
+	return &GraphSink{
+		path:  path,
+		dot:   ext == ".dot" || ext == ".gv",
+		edges: make(map[string]map[string]bool),
+	}
+}
+
+// Write adds the page and its links, and the link it was found through.
+func (s *GraphSink) Write(result crawler.Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.node(result.URL)
+	for _, link := range result.Links {
+		s.node(result.URL)[link] = true
+	}
+	if result.ParentURL != "" {
+		s.node(result.ParentURL)[result.URL] = true
+	}
+	return nil
+}
+
+// node returns the links recorded from pageURL, adding it to the graph.
+func (s *GraphSink) node(pageURL string) map[string]bool {
+	links, ok := s.edges[pageURL]
+	if !ok {
+		links = make(map[string]bool)
+		s.edges[pageURL] = links
+	}
+	return links
+}
+
+// Close writes the graph.
+func (s *GraphSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	adjacency := make(map[string][]string, len(s.edges))
+	for page, links := range s.edges {
+		targets := make([]string, 0, len(links))
+		for link := range links {
+			targets = append(targets, link)
+		}
+		sort.Strings(targets)
+		adjacency[page] = targets
+	}
+
+	file, err := os.Create(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open graph output: %v", err)
+	}
+	out := bufio.NewWriter(file)
+	if s.dot {
+		writeDOT(out, adjacency)
+	} else {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(adjacency); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to encode graph: %v", err)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write graph output: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write graph output: %v", err)
+	}
+	slog.Debug("Wrote link graph", "pages", len(adjacency), "path", s.path)
+	return nil
+}
+
+// writeDOT writes adjacency as a directed graph, pages in sorted order.
+func writeDOT(out *bufio.Writer, adjacency map[string][]string) {
+	pages := make([]string, 0, len(adjacency))
+	for page := range adjacency {
+		pages = append(pages, page)
+	}
+	sort.Strings(pages)
+
+	out.WriteString("digraph crawl {\n")
+	for _, page := range pages {
+		if len(adjacency[page]) == 0 {
+			fmt.Fprintf(out, "  %s;\n", dotQuote(page))
+		}
+		for _, link := range adjacency[page] {
+			fmt.Fprintf(out, "  %s -> %s;\n", dotQuote(page), dotQuote(link))
+		}
+	}
+	out.WriteString("}\n")
+}
+
+// dotQuote returns s as a DOT quoted string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,181 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"webcrawler/internal/crawler"
+	"webcrawler/internal/summarizer"
+)
+
+// jsonlRecord is the line written for each result.
+type jsonlRecord struct {
+	URL           string                        `json:"url"`
+	Depth         int                           `json:"depth"`
+	ParentURL     string                        `json:"parent_url,omitempty"`
+	StatusCode    int                           `json:"status_code,omitempty"`
+	ContentType   string                        `json:"content_type,omitempty"`
+	Title         string                        `json:"title,omitempty"`
+	Lang          string                        `json:"lang,omitempty"`
+	ContentLength int                           `json:"content_length"`
+	Summary       string                        `json:"summary,omitempty"`
+	Structured    *summarizer.StructuredSummary `json:"structured,omitempty"`
+	Error         string                        `json:"error,omitempty"`
+	Links         []string                      `json:"links,omitempty"`
+	Screenshot    string                        `json:"screenshot,omitempty"`
+	CrawledAt     time.Time                     `json:"crawled_at"`
+	LastModified  *time.Time                    `json:"last_modified,omitempty"`
+}
+
+// JSONLSink writes each result as a JSON object on its own line as soon as
+// it arrives, so memory use does not grow with the crawl.
+type JSONLSink struct {
+	mu  sync.Mutex
+	out *bufio.Writer
+	enc *json.Encoder
+
+	file *os.File
+	// With OutputMerge, new records go to a temporary file; on Close the
+	// previous records for URLs not crawled again are copied after them
+	// and the result replaces path.
+	path    string
+	merge   bool
+	written map[string]bool
+}
+
+// NewJSONLSink creates a sink writing to w, which it does not close.
+func NewJSONLSink(w io.Writer) *JSONLSink {
+	out := bufio.NewWriter(w)
+	return &JSONLSink{out: out, enc: json.NewEncoder(out)}
+}
+
+// OpenJSONLSink creates a sink writing to the file at path. OutputAppend
+// adds to an existing file, OutputOverwrite truncates it and OutputMerge
+// replaces the lines of URLs that are crawled again.
+func OpenJSONLSink(path string, mode crawler.OutputMode) (*JSONLSink, error) {
+	target, flags := path, os.O_CREATE|os.O_WRONLY
+	switch mode {
+	case crawler.OutputOverwrite:
+		flags |= os.O_TRUNC
+	case crawler.OutputMerge:
+		target = path + ".tmp"
+		flags |= os.O_TRUNC
+	default:
+		flags |= os.O_APPEND
+	}
+
+	file, err := os.OpenFile(target, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open JSONL output: %v", err)
+	}
+	s := NewJSONLSink(file)
+	s.file = file
+	s.path = path
+	if mode == crawler.OutputMerge {
+		s.merge = true
+		s.written = make(map[string]bool)
+	}
+	return s, nil
+}
+
+// Write appends result as one line.
+func (s *JSONLSink) Write(result crawler.Result) error {
+	record := jsonlRecord{
+		URL:           result.URL,
+		Depth:         result.Depth,
+		ParentURL:     result.ParentURL,
+		StatusCode:    result.StatusCode,
+		ContentType:   result.ContentType,
+		Title:         result.Title,
+		Lang:          result.Lang,
+		ContentLength: len(result.Content),
+		Summary:       result.Summary,
+		Structured:    result.Structured,
+		Links:         result.Links,
+		Screenshot:    result.ScreenshotPath,
+		CrawledAt:     result.CrawledAt,
+	}
+	if result.Error != nil {
+		record.Error = result.Error.Error()
+	}
+	if u := result.Understanding; u != nil && !u.LastModified.IsZero() {
+		record.LastModified = &u.LastModified
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.enc.Encode(record); err != nil {
+		return fmt.Errorf("failed to encode result: %v", err)
+	}
+	if s.merge {
+		s.written[result.URL] = true
+	}
+	// Flush per line so the output can be followed while the crawl runs.
+	return s.out.Flush()
+}
+
+// Close flushes the output and, when writing to a file, closes it.
+func (s *JSONLSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.merge {
+		if err := s.copyPrevious(); err != nil {
+			return err
+		}
+	}
+	if err := s.out.Flush(); err != nil {
+		return fmt.Errorf("failed to flush JSONL output: %v", err)
+	}
+	if s.file == nil {
+		return nil
+	}
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close JSONL output: %v", err)
+	}
+	if s.merge {
+		if err := os.Rename(s.file.Name(), s.path); err != nil {
+			return fmt.Errorf("failed to replace JSONL output: %v", err)
+		}
+	}
+	return nil
+}
+
+// copyPrevious copies the lines of the existing output whose URL was not
+// written in this run.
+func (s *JSONLSink) copyPrevious() error {
+	previous, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read previous JSONL output: %v", err)
+	}
+	defer previous.Close()
+
+	kept := 0
+	scanner := bufio.NewScanner(previous)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var record struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || s.written[record.URL] {
+			continue
+		}
+		s.out.Write(scanner.Bytes())
+		s.out.WriteByte('\n')
+		kept++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read previous JSONL output: %v", err)
+	}
+	slog.Debug("Kept previous results", "count", kept, "path", s.path)
+	return nil
+}
@@ -0,0 +1,160 @@
+package sink
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"sync"
+
+	"webcrawler/internal/crawler"
+)
+
+const schemaContext = "https://schema.org"
+
+// jsonldNode is a schema.org node in the document's @graph.
+type jsonldNode struct {
+	Type          string     `json:"@type"`
+	ID            string     `json:"@id"`
+	URL           string     `json:"url"`
+	Name          string     `json:"name,omitempty"`
+	Headline      string     `json:"headline,omitempty"`
+	Abstract      string     `json:"abstract,omitempty"`
+	Description   string     `json:"description,omitempty"`
+	DatePublished string     `json:"datePublished,omitempty"`
+	IsPartOf      *jsonldRef `json:"isPartOf,omitempty"`
+}
+
+type jsonldRef struct {
+	ID string `json:"@id"`
+}
+
+type jsonldDocument struct {
+	Context string        `json:"@context"`
+	Graph   []*jsonldNode `json:"@graph"`
+}
+
+// JSONLDSink collects crawled pages and writes them on Close as one JSON-LD
+// document: a WebSite node per host and a WebPage node per page (an
+// Article when the page declares a publication date), linked with
+// isPartOf.
+type JSONLDSink struct {
+	path string
+
+	mu    sync.Mutex
+	nodes []*jsonldNode
+	index map[string]int
+}
+
+// NewJSONLDSink creates a sink writing to path. With OutputOverwrite any
+// existing document is replaced; otherwise its nodes are kept, and with
+// OutputMerge a page crawled again replaces its earlier node.
+func NewJSONLDSink(path string, mode crawler.OutputMode) (*JSONLDSink, error) {
+	s := &JSONLDSink{
+		path:  path,
+		index: make(map[string]int),
+	}
+	if mode == crawler.OutputOverwrite {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON-LD output: %v", err)
+	}
+	var doc jsonldDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON-LD output %s: %v", path, err)
+	}
+	for _, node := range doc.Graph {
+		if mode == crawler.OutputMerge || node.Type == "WebSite" {
+			s.add(node)
+		} else {
+			s.nodes = append(s.nodes, node)
+		}
+	}
+	slog.Debug("Loaded JSON-LD nodes", "count", len(doc.Graph), "path", path)
+	return s, nil
+}
+
+// add inserts node, replacing an earlier node with the same @id.
+func (s *JSONLDSink) add(node *jsonldNode) {
+	if i, ok := s.index[node.ID]; ok {
+		s.nodes[i] = node
+		return
+	}
+	s.index[node.ID] = len(s.nodes)
+	s.nodes = append(s.nodes, node)
+}
+
+// Write adds a node for a successfully crawled page. Failed results are
+// skipped.
+func (s *JSONLDSink) Write(result crawler.Result) error {
+	if result.Error != nil || result.Skipped != "" {
+		return nil
+	}
+
+	page := &jsonldNode{
+		Type:          "WebPage",
+		ID:            result.URL,
+		URL:           result.URL,
+		Headline:      result.Title,
+		Abstract:      result.Summary,
+		Description:   result.Description,
+		DatePublished: result.Published,
+	}
+	if result.Published != "" {
+		page.Type = "Article"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if site := siteNode(result.URL); site != nil {
+		if _, ok := s.index[site.ID]; !ok {
+			s.add(site)
+		}
+		page.IsPartOf = &jsonldRef{ID: site.ID}
+	}
+	s.add(page)
+	return nil
+}
+
+// siteNode returns the WebSite node for the host of pageURL.
+func siteNode(pageURL string) *jsonldNode {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	root := u.Scheme + "://" + u.Host + "/"
+	return &jsonldNode{
+		Type: "WebSite",
+		ID:   root + "#website",
+		URL:  root,
+		Name: u.Hostname(),
+	}
+}
+
+// Close writes the document.
+func (s *JSONLDSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc := jsonldDocument{Context: schemaContext, Graph: s.nodes}
+	if doc.Graph == nil {
+		doc.Graph = []*jsonldNode{}
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON-LD: %v", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON-LD output: %v", err)
+	}
+	slog.Debug("Wrote JSON-LD nodes", "count", len(s.nodes), "path", s.path)
+	return nil
+}
@@ -0,0 +1,197 @@
+// Package storage persists crawled pages so that later runs, and other
+// tools, can use them. Stores implement crawler.Store.
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"webcrawler/internal/crawler"
+)
+
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS pages (
+	url           TEXT PRIMARY KEY,
+	depth         INTEGER NOT NULL,
+	status_code   INTEGER NOT NULL DEFAULT 0,
+	title         TEXT NOT NULL DEFAULT '',
+	content       TEXT NOT NULL DEFAULT '',
+	summary       TEXT NOT NULL DEFAULT '',
+	error         TEXT,
+	skipped       TEXT NOT NULL DEFAULT '',
+	fetched_at    TIMESTAMP NOT NULL,
+	last_modified TIMESTAMP
+)`
+
+// sqliteMigrations bring the pages table of databases created by earlier
+// versions up to sqliteSchema; each fails harmlessly once applied.
+var sqliteMigrations = []string{
+	`ALTER TABLE pages ADD COLUMN last_modified TIMESTAMP`,
+}
+
+const sqliteFrontierSchema = `CREATE TABLE IF NOT EXISTS frontier (
+	url            TEXT PRIMARY KEY,
+	depth          INTEGER NOT NULL,
+	parent         TEXT NOT NULL DEFAULT '',
+	relevance      REAL NOT NULL DEFAULT 0,
+	subdomain_hops INTEGER NOT NULL DEFAULT 0,
+	seq            INTEGER NOT NULL
+)`
+
+const sqliteUpsert = `INSERT INTO pages (url, depth, status_code, title, content, summary, error, skipped, fetched_at, last_modified)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(url) DO UPDATE SET
+	depth = excluded.depth,
+	status_code = excluded.status_code,
+	title = excluded.title,
+	content = excluded.content,
+	summary = excluded.summary,
+	error = excluded.error,
+	skipped = excluded.skipped,
+	fetched_at = excluded.fetched_at,
+	last_modified = excluded.last_modified`
+
+// SQLiteStore keeps one row per URL in a SQLite database, replacing the row
+// when a URL is crawled again. It also keeps the crawl's frontier, so it
+// implements crawler.FrontierStore.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens, creating if needed, the database at path. With
+// OutputOverwrite the pages of earlier runs are deleted; otherwise they
+// are kept, and a page crawled again replaces its row.
+func OpenSQLite(path string, mode crawler.OutputMode) (*SQLiteStore, error) {
+	db, err := openSQLiteDB(path + "?_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	// SQLite allows one writer at a time; workers take turns on a single
+	// connection rather than failing with "database is locked".
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create pages table in %s: %v", path, err)
+	}
+	for _, migration := range sqliteMigrations {
+		if _, err := db.Exec(migration); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate pages table in %s: %v", path, err)
+		}
+	}
+	if _, err := db.Exec(sqliteFrontierSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create frontier table in %s: %v", path, err)
+	}
+	if mode == crawler.OutputOverwrite {
+		if _, err := db.Exec(`DELETE FROM pages; DELETE FROM frontier`); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to clear pages in %s: %v", path, err)
+		}
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pages`).Scan(&count); err == nil && count > 0 {
+		slog.Debug("Database has pages from earlier crawls", "path", path, "pages", count)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Save stores result, replacing any earlier row for its URL.
+func (s *SQLiteStore) Save(result crawler.Result) error {
+	var errText sql.NullString
+	if result.Error != nil {
+		errText = sql.NullString{String: result.Error.Error(), Valid: true}
+	}
+	var lastModified sql.NullTime
+	if result.Understanding != nil && !result.Understanding.LastModified.IsZero() {
+		lastModified = sql.NullTime{Time: result.Understanding.LastModified, Valid: true}
+	}
+	if _, err := s.db.Exec(sqliteUpsert,
+		result.URL,
+		result.Depth,
+		result.StatusCode,
+		result.Title,
+		result.Content,
+		result.Summary,
+		errText,
+		string(result.Skipped),
+		result.CrawledAt,
+		lastModified,
+	); err != nil {
+		return fmt.Errorf("failed to save %s: %v", result.URL, err)
+	}
+	return nil
+}
+
+// Seen reports whether urlStr was crawled successfully before. Pages that
+// failed or were skipped don't count, so they are tried again.
+func (s *SQLiteStore) Seen(urlStr string) (bool, error) {
+	var seen bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM pages WHERE url = ? AND error IS NULL AND skipped = '')`, urlStr).Scan(&seen)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up %s: %v", urlStr, err)
+	}
+	return seen, nil
+}
+
+// Enqueue adds item to the saved frontier. A URL already there keeps its
+// place.
+func (s *SQLiteStore) Enqueue(item crawler.FrontierItem) error {
+	_, err := s.db.Exec(`INSERT INTO frontier (url, depth, parent, relevance, subdomain_hops, seq)
+VALUES (?, ?, ?, ?, ?, (SELECT COALESCE(MAX(seq), 0) + 1 FROM frontier))
+ON CONFLICT(url) DO NOTHING`,
+		item.URL, item.Depth, item.ParentURL, item.Relevance, item.SubdomainHops)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s: %v", item.URL, err)
+	}
+	return nil
+}
+
+// Dequeue removes urlStr from the saved frontier.
+func (s *SQLiteStore) Dequeue(urlStr string) error {
+	if _, err := s.db.Exec(`DELETE FROM frontier WHERE url = ?`, urlStr); err != nil {
+		return fmt.Errorf("failed to dequeue %s: %v", urlStr, err)
+	}
+	return nil
+}
+
+// Pending returns the saved frontier in the order it was enqueued.
+func (s *SQLiteStore) Pending() ([]crawler.FrontierItem, error) {
+	rows, err := s.db.Query(`SELECT url, depth, parent, relevance, subdomain_hops FROM frontier ORDER BY seq`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read frontier: %v", err)
+	}
+	defer rows.Close()
+
+	var items []crawler.FrontierItem
+	for rows.Next() {
+		var item crawler.FrontierItem
+		if err := rows.Scan(&item.URL, &item.Depth, &item.ParentURL, &item.Relevance, &item.SubdomainHops); err != nil {
+			return nil, fmt.Errorf("failed to read frontier: %v", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read frontier: %v", err)
+	}
+	return items, nil
+}
+
+// ClearFrontier empties the saved frontier.
+func (s *SQLiteStore) ClearFrontier() error {
+	if _, err := s.db.Exec(`DELETE FROM frontier`); err != nil {
+		return fmt.Errorf("failed to clear frontier: %v", err)
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() error {
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build cgo
+
+package storage
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// openSQLiteDB opens dsn with the mattn/go-sqlite3 driver, which is a
+// wrapper around the C library and so only builds with cgo.
+func openSQLiteDB(dsn string) (*sql.DB, error) {
+	return sql.Open("sqlite3", dsn)
+}
@@ -0,0 +1,14 @@
+//go:build !cgo
+
+package storage
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// openSQLiteDB fails in binaries built without cgo, which the SQLite
+// driver needs; the rest of the crawler works without it.
+func openSQLiteDB(string) (*sql.DB, error) {
+	return nil, errors.New("SQLite support needs cgo: rebuild with CGO_ENABLED=1 and a C compiler")
+}
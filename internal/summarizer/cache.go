@@ -0,0 +1,248 @@
+package summarizer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// SummaryCache keeps summaries on disk, one file per model and SHA-256 of
+// the input they were made from, so that input seen before is not sent to
+// the model again.
+type SummaryCache struct {
+	dir string
+}
+
+// NewSummaryCache returns a cache kept in dir, which is created when the
+// first summary is stored.
+func NewSummaryCache(dir string) *SummaryCache {
+	return &SummaryCache{dir: dir}
+}
+
+// Get returns the summary model made of input, if cached.
+func (c *SummaryCache) Get(model, input string) (string, bool) {
+	data, err := os.ReadFile(c.path(model, input))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Put caches the summary model made of input.
+func (c *SummaryCache) Put(model, input, summary string) error {
+	dir := c.modelDir(model)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create summary cache: %v", err)
+	}
+	// Write to a temporary file first so that a concurrent Get never reads
+	// a partial summary.
+	tmp, err := os.CreateTemp(dir, "summary-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write summary cache: %v", err)
+	}
+	if _, err := tmp.WriteString(summary); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write summary cache: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write summary cache: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(model, input)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write summary cache: %v", err)
+	}
+	return nil
+}
+
+// Bust removes every summary cached for model.
+func (c *SummaryCache) Bust(model string) error {
+	if err := os.RemoveAll(c.modelDir(model)); err != nil {
+		return fmt.Errorf("failed to clear summary cache for %s: %v", model, err)
+	}
+	return nil
+}
+
+func (c *SummaryCache) path(model, input string) string {
+	sum := sha256.Sum256([]byte(input))
+	return filepath.Join(c.modelDir(model), hex.EncodeToString(sum[:])+".txt")
+}
+
+// modelDir returns the directory of model's summaries, its name with
+// anything but letters, digits, '.', '-' and '_' replaced so that names
+// like "llama3:8b" are safe on every filesystem.
+func (c *SummaryCache) modelDir(model string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, model)
+	if name == "" || strings.Trim(name, ".") == "" {
+		name = "_"
+	}
+	return filepath.Join(c.dir, name)
+}
+
+// CachingSummarizer wraps a Summarizer, answering input it has summarized
+// before from a SummaryCache instead of calling the model. When the
+// wrapped summarizer is a PageSummarizer, so is the wrapper: documents,
+// discussions and comparisons are cached too, while critiques and health
+// checks are passed straight through.
+type CachingSummarizer struct {
+	inner Summarizer
+	cache *SummaryCache
+	model string
+}
+
+// NewCachingSummarizer caches inner's summaries in dir, keyed by inner's
+// model (when it reports one), format and prompt and the input summarized.
+func NewCachingSummarizer(inner Summarizer, dir string) *CachingSummarizer {
+	model := "default"
+	if named, ok := inner.(interface{ Model() string }); ok {
+		model = named.Model()
+	}
+	return &CachingSummarizer{inner: inner, cache: NewSummaryCache(dir), model: model}
+}
+
+// Summarize returns the cached summary of text, or has the wrapped
+// summarizer make one and caches it. A summary that can't be cached is
+// still returned.
+func (s *CachingSummarizer) Summarize(ctx context.Context, text string) (string, error) {
+	return s.cached(s.key("text", text), func() (string, error) {
+		return s.inner.Summarize(ctx, text)
+	})
+}
+
+// SummarizeDocument is Summarize for a page, cached by its title,
+// description, language and text along with prompt.
+func (s *CachingSummarizer) SummarizeDocument(ctx context.Context, doc Document, prompt *template.Template) (string, error) {
+	page, err := s.page()
+	if err != nil {
+		return "", err
+	}
+	return s.cached(s.documentKey(doc, prompt), func() (string, error) {
+		return page.SummarizeDocument(ctx, doc, prompt)
+	})
+}
+
+// CachedDocument returns the summary SummarizeDocument would reuse for doc,
+// if there is one, without calling the model.
+func (s *CachingSummarizer) CachedDocument(doc Document, prompt *template.Template) (string, bool) {
+	return s.cache.Get(s.model, s.documentKey(doc, prompt))
+}
+
+func (s *CachingSummarizer) SummarizeDiscussion(ctx context.Context, comments string) (string, error) {
+	page, err := s.page()
+	if err != nil {
+		return "", err
+	}
+	return s.cached(s.key("discussion", comments), func() (string, error) {
+		return page.SummarizeDiscussion(ctx, comments)
+	})
+}
+
+func (s *CachingSummarizer) Compare(ctx context.Context, a, b Document) (string, error) {
+	page, err := s.page()
+	if err != nil {
+		return "", err
+	}
+	key := s.key("compare", a.Title, a.Description, a.Lang, a.Text, b.Title, b.Description, b.Lang, b.Text)
+	return s.cached(key, func() (string, error) {
+		return page.Compare(ctx, a, b)
+	})
+}
+
+func (s *CachingSummarizer) Critique(ctx context.Context, text, summary string) (Critique, error) {
+	page, err := s.page()
+	if err != nil {
+		return Critique{}, err
+	}
+	return page.Critique(ctx, text, summary)
+}
+
+func (s *CachingSummarizer) HealthCheck(ctx context.Context) error {
+	if page, ok := s.inner.(PageSummarizer); ok {
+		return page.HealthCheck(ctx)
+	}
+	return nil
+}
+
+func (s *CachingSummarizer) Ready(ctx context.Context) error {
+	if page, ok := s.inner.(PageSummarizer); ok {
+		return page.Ready(ctx)
+	}
+	return nil
+}
+
+func (s *CachingSummarizer) Model() string {
+	return s.model
+}
+
+func (s *CachingSummarizer) Format() Format {
+	if page, ok := s.inner.(PageSummarizer); ok {
+		return page.Format()
+	}
+	return ""
+}
+
+func (s *CachingSummarizer) PromptTemplate() string {
+	if page, ok := s.inner.(PageSummarizer); ok {
+		return page.PromptTemplate()
+	}
+	return ""
+}
+
+// Bust removes every summary cached for the wrapped summarizer's model.
+func (s *CachingSummarizer) Bust() error {
+	return s.cache.Bust(s.model)
+}
+
+// page returns the wrapped summarizer for the methods only a
+// PageSummarizer has.
+func (s *CachingSummarizer) page() (PageSummarizer, error) {
+	page, ok := s.inner.(PageSummarizer)
+	if !ok {
+		return nil, fmt.Errorf("%T can only summarize plain text", s.inner)
+	}
+	return page, nil
+}
+
+// cached returns the summary cached under key, or calls generate and
+// caches what it returns.
+func (s *CachingSummarizer) cached(key string, generate func() (string, error)) (string, error) {
+	if summary, ok := s.cache.Get(s.model, key); ok {
+		return summary, nil
+	}
+	summary, err := generate()
+	if err != nil {
+		return "", err
+	}
+	if err := s.cache.Put(s.model, key, summary); err != nil {
+		slog.Warn("Failed to cache summary", "error", err)
+	}
+	return summary, nil
+}
+
+// key is the cache input for a call of kind with parts: the format and
+// prompt template are included so that changing either makes new
+// summaries.
+func (s *CachingSummarizer) key(kind string, parts ...string) string {
+	return strings.Join(append([]string{kind, string(s.Format()), s.PromptTemplate()}, parts...), "\x00")
+}
+
+func (s *CachingSummarizer) documentKey(doc Document, prompt *template.Template) string {
+	var promptText string
+	if prompt != nil && prompt.Tree != nil {
+		promptText = prompt.Tree.Root.String()
+	}
+	return s.key("document", promptText, doc.Title, doc.Description, doc.Lang, doc.Text)
+}
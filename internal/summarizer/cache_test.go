@@ -0,0 +1,129 @@
+package summarizer
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// countingSummarizer is a PageSummarizer that counts calls to the model.
+type countingSummarizer struct {
+	PageSummarizer
+	format Format
+	calls  int
+}
+
+func (s *countingSummarizer) Summarize(ctx context.Context, text string) (string, error) {
+	s.calls++
+	return "summary of " + text, nil
+}
+
+func (s *countingSummarizer) SummarizeDocument(ctx context.Context, doc Document, prompt *template.Template) (string, error) {
+	s.calls++
+	return "summary of " + doc.Text, nil
+}
+
+func (s *countingSummarizer) Compare(ctx context.Context, a, b Document) (string, error) {
+	s.calls++
+	return a.Text + " vs " + b.Text, nil
+}
+
+func (s *countingSummarizer) Model() string          { return "llama3:8b" }
+func (s *countingSummarizer) Format() Format         { return s.format }
+func (s *countingSummarizer) PromptTemplate() string { return "" }
+
+func TestCachingSummarizer(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	inner := &countingSummarizer{format: FormatParagraph}
+	s := NewCachingSummarizer(inner, dir)
+
+	doc := Document{URL: "https://example.com/a", Title: "A", Text: "Some text."}
+	if _, ok := s.CachedDocument(doc, nil); ok {
+		t.Fatal("CachedDocument found a summary in an empty cache")
+	}
+	for range 2 {
+		summary, err := s.SummarizeDocument(ctx, doc, nil)
+		if err != nil || summary != "summary of Some text." {
+			t.Fatalf("SummarizeDocument() = %q, %v", summary, err)
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("model called %d times for the same document, want 1", inner.calls)
+	}
+	if summary, ok := s.CachedDocument(doc, nil); !ok || summary != "summary of Some text." {
+		t.Errorf("CachedDocument() = %q, %v", summary, ok)
+	}
+
+	// The same text at another URL is answered from the cache; another
+	// prompt, another format or another title is not.
+	moved := doc
+	moved.URL = "https://example.com/b"
+	s.SummarizeDocument(ctx, moved, nil)
+	if inner.calls != 1 {
+		t.Errorf("model called again for the same content at another URL")
+	}
+	s.SummarizeDocument(ctx, doc, template.Must(ParsePrompt("Summarize: {{.Text}}")))
+	retitled := doc
+	retitled.Title = "B"
+	s.SummarizeDocument(ctx, retitled, nil)
+	inner.format = FormatBullets
+	s.SummarizeDocument(ctx, doc, nil)
+	if inner.calls != 4 {
+		t.Errorf("model called %d times, want a new summary for each prompt, title and format", inner.calls)
+	}
+
+	// Comparisons are cached too.
+	for range 2 {
+		if _, err := s.Compare(ctx, doc, retitled); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if inner.calls != 5 {
+		t.Errorf("model called %d times after comparing twice, want 5", inner.calls)
+	}
+
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 1 || entries[0].Name() != "llama3_8b" {
+		t.Errorf("cache directory holds %v (%v), want one llama3_8b directory", entries, err)
+	}
+	if err := s.Bust(); err != nil {
+		t.Fatal(err)
+	}
+	s.SummarizeDocument(ctx, doc, nil)
+	if inner.calls != 6 {
+		t.Errorf("model not called after busting the cache")
+	}
+}
+
+// plainSummarizer only implements Summarizer.
+type plainSummarizer func(string) string
+
+func (f plainSummarizer) Summarize(ctx context.Context, text string) (string, error) {
+	return f(text), nil
+}
+
+func TestCachingSummarizerPlain(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	s := NewCachingSummarizer(plainSummarizer(func(text string) string {
+		calls++
+		return strings.ToUpper(text)
+	}), t.TempDir())
+
+	for range 2 {
+		if summary, err := s.Summarize(ctx, "text"); err != nil || summary != "TEXT" {
+			t.Fatalf("Summarize() = %q, %v", summary, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("model called %d times, want 1", calls)
+	}
+	if s.Model() != "default" {
+		t.Errorf("Model() = %q, want default", s.Model())
+	}
+	if _, err := s.SummarizeDocument(ctx, Document{Text: "text"}, nil); err == nil {
+		t.Error("SummarizeDocument succeeded with a summarizer that only summarizes text")
+	}
+}
@@ -0,0 +1,109 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// defaultChunkSize is the size, in bytes, of the chunks long text is split
+// into when ChunkSize is not set.
+const defaultChunkSize = 8000
+
+const chunkPrompt = `You are a helpful AI assistant. The text below is part %d of %d of a longer document%s. Summarize this part in a few concise bullet points, keeping the key facts, names and figures, so that it can be combined with the summaries of the other parts.
+
+Text: %s`
+
+// summarizeChunks summarizes text too long for one call by summarizing
+// overlapping chunks of it and then the combined chunk summaries, with
+// prompt or the format's prompt. This repeats while the combined summaries
+// are still too long.
+func (o *OllamaSummarizer) summarizeChunks(ctx context.Context, doc Document, prompt *template.Template) (string, error) {
+	size := o.ChunkSize
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	size = min(size, o.maxInputLen())
+
+	var titled string
+	if doc.Title != "" {
+		titled = fmt.Sprintf(" titled %q", doc.Title)
+	}
+
+	for len(doc.Text) > o.maxInputLen() {
+		chunks := splitChunks(doc.Text, size, size/10)
+		o.logger.Debug("Summarizing in chunks", "bytes", len(doc.Text), "chunks", len(chunks))
+
+		var partials strings.Builder
+		for i, chunk := range chunks {
+			summary, err := o.generate(ctx, splitPrompt(chunkPrompt, i+1, len(chunks), titled, chunk))
+			if err != nil {
+				return "", fmt.Errorf("failed to summarize chunk %d of %d: %v", i+1, len(chunks), err)
+			}
+			fmt.Fprintf(&partials, "Part %d:\n%s\n\n", i+1, strings.TrimSpace(summary))
+		}
+
+		combined := strings.TrimSpace(partials.String())
+		if len(combined) >= len(doc.Text) {
+			// The chunk summaries did not get any shorter; summarizing them
+			// again would not either, so let the final call truncate them.
+			doc.Text = combined
+			break
+		}
+		doc.Text = combined
+	}
+
+	rendered, err := summaryPrompt(o.logger, o.format, doc, prompt, o.maxInputLen())
+	if err != nil {
+		return "", err
+	}
+	return o.generate(ctx, rendered)
+}
+
+// splitChunks splits text into chunks of at most size bytes, each starting
+// about overlap bytes before the previous one ended. Chunks end at a
+// paragraph, sentence or word break in their second half where there is
+// one, and never inside a multibyte character.
+func splitChunks(text string, size, overlap int) []string {
+	var chunks []string
+	for start := 0; start < len(text); {
+		end := start + size
+		if end >= len(text) {
+			chunks = append(chunks, strings.TrimSpace(text[start:]))
+			break
+		}
+		end = chunkEnd(text, start+size/2, end)
+		chunks = append(chunks, strings.TrimSpace(text[start:end]))
+
+		next := end - overlap
+		if next <= start {
+			next = end
+		} else if i := strings.IndexAny(text[next:end], " \n"); i >= 0 {
+			// Start the overlap on a word.
+			next += i + 1
+		}
+		for next < end && !utf8.RuneStart(text[next]) {
+			next++
+		}
+		start = next
+	}
+	return chunks
+}
+
+// chunkEnd returns where a chunk ending at most at end should end: after
+// the last paragraph break, sentence end or space at or after from, or
+// else at the last rune boundary before end.
+func chunkEnd(text string, from, end int) int {
+	window := text[from:end]
+	for _, sep := range []string{"\n\n", ". ", "\n", " "} {
+		if i := strings.LastIndex(window, sep); i >= 0 {
+			return from + i + len(sep)
+		}
+	}
+	for end > from && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return end
+}
@@ -1,6 +1,10 @@
 package summarizer
 
-import "fmt"
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
 
 // Type represents the type of summarizer to use
 type Type string
@@ -8,6 +12,8 @@ type Type string
 const (
 	// TypeOllama represents the Ollama summarizer
 	TypeOllama Type = "ollama"
+	// TypeOpenAI represents the OpenAI (or OpenAI-compatible) summarizer
+	TypeOpenAI Type = "openai"
 )
 
 // Config holds configuration for summarizer creation
@@ -16,6 +22,26 @@ type Config struct {
 	// Ollama specific config
 	OllamaURL   string
 	OllamaModel string
+	// MaxInputLen and ChunkSize set the Ollama summarizer's fields of the
+	// same name when non-zero.
+	MaxInputLen int
+	ChunkSize   int
+	// Stream, IdleTimeout and UseChatAPI set the Ollama summarizer's
+	// fields of the same name.
+	Stream      bool
+	IdleTimeout time.Duration
+	UseChatAPI  bool
+	// OpenAI specific config; an empty base URL uses api.openai.com
+	OpenAIKey     string
+	OpenAIBaseURL string
+	OpenAIModel   string
+
+	Format Format
+	// PromptTemplate replaces the format's prompt when set; see
+	// ParsePrompt.
+	PromptTemplate string
+	// Logger is used instead of slog.Default() when set.
+	Logger *slog.Logger
 }
 
 // Factory creates summarizers based on configuration
@@ -31,10 +57,48 @@ func NewFactory(config Config) *Factory {
 }
 
 // CreateSummarizer creates a summarizer based on the configuration
-func (f *Factory) CreateSummarizer() (Summarizer, error) {
+func (f *Factory) CreateSummarizer() (PageSummarizer, error) {
 	switch f.config.Type {
 	case TypeOllama:
-		return NewOllamaSummarizer(f.config.OllamaURL, f.config.OllamaModel), nil
+		format, err := ParseFormat(string(f.config.Format))
+		if err != nil {
+			return nil, err
+		}
+		opts := []Option{WithFormat(format), WithLogger(f.config.Logger)}
+		if f.config.PromptTemplate != "" {
+			opts = append(opts, WithPromptTemplate(f.config.PromptTemplate))
+		}
+		s, err := NewOllamaSummarizer(f.config.OllamaURL, f.config.OllamaModel, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if f.config.MaxInputLen > 0 {
+			s.MaxInputLen = f.config.MaxInputLen
+		}
+		if f.config.ChunkSize > 0 {
+			s.ChunkSize = f.config.ChunkSize
+		}
+		s.Stream = f.config.Stream
+		s.IdleTimeout = f.config.IdleTimeout
+		s.UseChatAPI = f.config.UseChatAPI
+		return s, nil
+	case TypeOpenAI:
+		format, err := ParseFormat(string(f.config.Format))
+		if err != nil {
+			return nil, err
+		}
+		s := NewOpenAISummarizer(f.config.OpenAIBaseURL, f.config.OpenAIKey, f.config.OpenAIModel)
+		s.format = format
+		if f.config.Logger != nil {
+			s.logger = f.config.Logger
+		}
+		if f.config.PromptTemplate != "" {
+			if s.prompt, err = ParsePrompt(f.config.PromptTemplate); err != nil {
+				return nil, err
+			}
+			s.promptText = f.config.PromptTemplate
+		}
+		return s, nil
 	default:
 		return nil, fmt.Errorf("unsupported summarizer type: %s", f.config.Type)
 	}
@@ -0,0 +1,79 @@
+package summarizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format selects the shape of the generated summary.
+type Format string
+
+const (
+	FormatStructured Format = "structured"
+	FormatParagraph  Format = "paragraph"
+	FormatBullets    Format = "bullets"
+	FormatQA         Format = "qa"
+	FormatTLDR       Format = "tldr"
+
+	// FormatJSON asks for the sections of FormatStructured as a JSON
+	// object, which ParseStructuredSummary reads.
+	FormatJSON Format = "json"
+)
+
+// formatPrompts holds the prompt for each format; %s is replaced with the
+// page text.
+var formatPrompts = map[Format]string{
+	FormatStructured: `You are a helpful AI assistant. Create a structured summary of this text with:
+
+1. Key Points (3-4 bullet points)
+2. Important Terms (3-4 terms with brief explanations)
+3. Main Takeaways (2-3 points)
+
+Text: %s
+
+Remember to be concise and specific.`,
+
+	FormatParagraph: `You are a helpful AI assistant. Summarize this text in a single paragraph of 4-6 sentences of plain prose, without headings or bullet points.
+
+Text: %s
+
+Cover the main subject and the most important details.`,
+
+	FormatBullets: `You are a helpful AI assistant. Summarize this text as 5-7 bullet points, one line each, starting each line with "- ". Do not add headings or any text before or after the list.
+
+Text: %s`,
+
+	FormatQA: `You are a helpful AI assistant. Summarize this text as 3-5 questions a reader would likely ask about it, each followed by a short answer taken from the text. Use this layout:
+
+Q: <question>
+A: <answer>
+
+Text: %s
+
+Only answer from the text; do not invent facts.`,
+
+	FormatTLDR: `You are a helpful AI assistant. Give a TL;DR of this text: one or two sentences, at most 40 words, with no preamble.
+
+Text: %s`,
+
+	FormatJSON: `You are a helpful AI assistant. Summarize this text as a JSON object with exactly these fields:
+
+{"key_points": ["3-4 key points"], "terms": [{"term": "an important term", "definition": "a brief explanation"}], "takeaways": ["2-3 main takeaways"]}
+
+Give 3-4 terms. Reply with the JSON object only, without Markdown or any other text.
+
+Text: %s`,
+}
+
+// ParseFormat returns the Format named by name, defaulting to
+// FormatStructured when name is empty.
+func ParseFormat(name string) (Format, error) {
+	if name == "" {
+		return FormatStructured, nil
+	}
+	format := Format(strings.ToLower(name))
+	if _, ok := formatPrompts[format]; !ok {
+		return "", fmt.Errorf("unknown summary format %q (want structured, paragraph, bullets, qa, tldr or json)", name)
+	}
+	return format, nil
+}
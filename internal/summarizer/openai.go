@@ -0,0 +1,223 @@
+package summarizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com"
+	defaultOpenAIModel   = "gpt-4o-mini"
+)
+
+// OpenAISummarizer summarizes with the OpenAI chat completions API, or any
+// server compatible with it such as vLLM or LocalAI.
+type OpenAISummarizer struct {
+	baseURL string
+	apiKey  string
+	model   string
+	format  Format
+	prompt  *template.Template
+	// promptText is the source of prompt, for PromptTemplate.
+	promptText string
+	logger     *slog.Logger
+}
+
+// NewOpenAISummarizer creates a summarizer posting to baseURL's
+// /v1/chat/completions. An empty baseURL uses api.openai.com; apiKey may be
+// empty for local servers that don't check it.
+func NewOpenAISummarizer(baseURL, apiKey, model string) *OpenAISummarizer {
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	// Accept the base URL with or without the API version.
+	baseURL = strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1")
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	return &OpenAISummarizer{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		model:   model,
+		format:  FormatStructured,
+		logger:  slog.Default(),
+	}
+}
+
+// Model returns the name of the model used.
+func (o *OpenAISummarizer) Model() string {
+	return o.model
+}
+
+// Format returns the summary format the summarizer produces.
+func (o *OpenAISummarizer) Format() Format {
+	return o.format
+}
+
+// PromptTemplate returns the custom prompt template, or "" when the
+// format's prompt is used.
+func (o *OpenAISummarizer) PromptTemplate() string {
+	return o.promptText
+}
+
+// HealthCheck reports whether the server is reachable and accepts the API
+// key, by listing its models.
+func (o *OpenAISummarizer) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/models", o.baseURL), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("openai server is not reachable at %s: %v", o.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("openai health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Ready is HealthCheck: hosted models need no loading.
+func (o *OpenAISummarizer) Ready(ctx context.Context) error {
+	return o.HealthCheck(ctx)
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Summarize generates a summary of the given text, giving up, including
+// between retries, as soon as ctx is cancelled.
+func (o *OpenAISummarizer) Summarize(ctx context.Context, text string) (string, error) {
+	return o.SummarizeWithPrompt(ctx, text, nil)
+}
+
+// SummarizeWithPrompt summarizes text using a custom prompt template from
+// ParsePrompt instead of the format's prompt. A nil prompt uses the format.
+func (o *OpenAISummarizer) SummarizeWithPrompt(ctx context.Context, text string, prompt *template.Template) (string, error) {
+	return o.SummarizeDocument(ctx, Document{Text: text}, prompt)
+}
+
+// SummarizeDocument is like SummarizeWithPrompt but also gives the model
+// the page's title and description.
+func (o *OpenAISummarizer) SummarizeDocument(ctx context.Context, doc Document, prompt *template.Template) (string, error) {
+	if prompt == nil {
+		prompt = o.prompt
+	}
+	rendered, err := summaryPrompt(o.logger, o.format, doc, prompt, maxInputLen)
+	if err != nil {
+		return "", err
+	}
+	return o.generate(ctx, rendered.text)
+}
+
+// Compare asks the model for the key differences between two pages.
+func (o *OpenAISummarizer) Compare(ctx context.Context, a, b Document) (string, error) {
+	prompt, err := compareRequest(a, b, maxInputLen)
+	if err != nil {
+		return "", err
+	}
+	return o.generate(ctx, prompt.text)
+}
+
+// SummarizeDiscussion summarizes a page's comment section, focusing on
+// themes and opinions rather than facts.
+func (o *OpenAISummarizer) SummarizeDiscussion(ctx context.Context, comments string) (string, error) {
+	prompt, err := discussionRequest(comments, maxInputLen)
+	if err != nil {
+		return "", err
+	}
+	return o.generate(ctx, prompt.text)
+}
+
+// Critique asks the model to rate summary against the text it was made
+// from.
+func (o *OpenAISummarizer) Critique(ctx context.Context, text, summary string) (Critique, error) {
+	prompt, err := critiqueRequest(text, summary, maxInputLen)
+	if err != nil {
+		return Critique{}, err
+	}
+	response, err := o.generate(ctx, prompt.text)
+	if err != nil {
+		return Critique{}, err
+	}
+	return parseCritique(response)
+}
+
+// generate sends prompt as a single user message, retrying failed requests,
+// and returns the reply.
+func (o *OpenAISummarizer) generate(ctx context.Context, promptText string) (string, error) {
+	jsonData, err := json.Marshal(chatRequest{
+		Model:    o.model,
+		Messages: []chatMessage{{Role: "user", Content: promptText}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	return withRetries(ctx, o.logger, func() (string, error) {
+		return o.makeRequest(ctx, jsonData)
+	})
+}
+
+func (o *OpenAISummarizer) makeRequest(ctx context.Context, jsonData []byte) (string, error) {
+	client := requestClient(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/chat/completions", o.baseURL), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response (status %d): %v", resp.StatusCode, err)
+	}
+	if result.Error != nil {
+		return "", fmt.Errorf("openai error: %s", result.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("openai request returned status %d", resp.StatusCode)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("openai response has no choices")
+	}
+	return result.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,107 @@
+package summarizer
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// PromptData is what custom prompt templates are executed with.
+type PromptData struct {
+	// Text is the page content to summarize.
+	Text string
+
+	// URL, Title and Description describe the page; each may be empty.
+	URL         string
+	Title       string
+	Description string
+
+	// Lang is the page's language code ("en"), or empty when unknown, for
+	// templates that ask for the summary in the page's language.
+	Lang string
+}
+
+// ParsePrompt compiles a custom prompt template. The page content is
+// available as {{.Text}}, which the template must reference, and its
+// URL, title, description and language as {{.URL}}, {{.Title}},
+// {{.Description}} and {{.Lang}}.
+func ParsePrompt(text string) (*template.Template, error) {
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt template: %v", err)
+	}
+
+	var probe strings.Builder
+	if err := tmpl.Execute(&probe, PromptData{Text: "\x00"}); err != nil {
+		return nil, fmt.Errorf("invalid prompt template: %v", err)
+	}
+	if !strings.Contains(probe.String(), "\x00") {
+		return nil, fmt.Errorf("invalid prompt template: it does not include {{.Text}}")
+	}
+	return tmpl, nil
+}
+
+// renderedPrompt is a prompt ready to send. The generate API gets it whole,
+// as text; the chat API gets its instructions as a system message and the
+// content they apply to as a user message.
+type renderedPrompt struct {
+	text   string
+	system string
+	user   string
+}
+
+// splitPrompt renders a built-in prompt, format being its fmt layout. The
+// paragraphs of format holding a verb, and so the content, make up the
+// user message, and the others the system message.
+func splitPrompt(format string, args ...any) renderedPrompt {
+	var system, user []string
+	for _, paragraph := range strings.Split(format, "\n\n") {
+		if strings.Contains(paragraph, "%") {
+			user = append(user, paragraph)
+		} else {
+			system = append(system, paragraph)
+		}
+	}
+	return renderedPrompt{
+		text:   fmt.Sprintf(format, args...),
+		system: strings.Join(system, "\n\n"),
+		user:   fmt.Sprintf(strings.Join(user, "\n\n"), args...),
+	}
+}
+
+// buildPrompt renders tmpl for doc, or format's built-in prompt when tmpl
+// is nil. The built-in prompts get the title and description, when known,
+// ahead of the text. A custom template is sent whole as the user message.
+func buildPrompt(format Format, doc Document, tmpl *template.Template) (renderedPrompt, error) {
+	if tmpl != nil {
+		var prompt strings.Builder
+		data := PromptData{
+			Text:        doc.Text,
+			URL:         doc.URL,
+			Title:       doc.Title,
+			Description: doc.Description,
+			Lang:        doc.Lang,
+		}
+		if err := tmpl.Execute(&prompt, data); err != nil {
+			return renderedPrompt{}, fmt.Errorf("failed to render prompt: %v", err)
+		}
+		return renderedPrompt{text: prompt.String(), user: prompt.String()}, nil
+	}
+
+	prompt, ok := formatPrompts[format]
+	if !ok {
+		return renderedPrompt{}, fmt.Errorf("unknown summary format %q", format)
+	}
+	var header strings.Builder
+	if doc.Title != "" {
+		header.WriteString("Page title: " + doc.Title + "\n")
+	}
+	if doc.Description != "" {
+		header.WriteString("Page description: " + doc.Description + "\n")
+	}
+	text := doc.Text
+	if header.Len() > 0 {
+		text = header.String() + "\n" + text
+	}
+	return splitPrompt(prompt, text), nil
+}
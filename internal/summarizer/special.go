@@ -0,0 +1,158 @@
+package summarizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Document is a page handed to Compare or SummarizeDocument.
+type Document struct {
+	URL         string
+	Title       string
+	Description string
+	Text        string
+	// Lang is the page's language code, or empty when unknown.
+	Lang string
+}
+
+const comparePrompt = `You are a helpful AI assistant. Compare the two web pages below and report how they differ. Structure the answer as:
+
+1. Summary of Differences (2-3 sentences)
+2. Only in Page A (bullet points)
+3. Only in Page B (bullet points)
+4. Changed (bullet points: what Page A says vs what Page B says)
+
+If the pages are essentially the same, say so.
+
+Page A (%s, %q):
+%s
+
+Page B (%s, %q):
+%s
+
+Be concise and specific; do not describe what the pages have in common at length.`
+
+// Compare asks the model for the key differences between two pages.
+func (o *OllamaSummarizer) Compare(ctx context.Context, a, b Document) (string, error) {
+	prompt, err := compareRequest(a, b, o.maxInputLen())
+	if err != nil {
+		return "", err
+	}
+	return o.generate(ctx, prompt)
+}
+
+// compareRequest renders the prompt for comparing a and b, keeping the
+// pair within maxLen bytes of text.
+func compareRequest(a, b Document, maxLen int) (renderedPrompt, error) {
+	textA := strings.TrimSpace(a.Text)
+	textB := strings.TrimSpace(b.Text)
+	if textA == "" || textB == "" {
+		return renderedPrompt{}, fmt.Errorf("empty text")
+	}
+
+	// Both pages share one prompt, so each gets half the usual budget.
+	textA = truncateMiddle(textA, maxLen/2)
+	textB = truncateMiddle(textB, maxLen/2)
+
+	return splitPrompt(comparePrompt, a.URL, a.Title, textA, b.URL, b.Title, textB), nil
+}
+
+const discussionPrompt = `You are a helpful AI assistant. Summarize the discussion in these user comments with:
+
+1. Main Themes (3-4 bullet points)
+2. Points of Agreement and Disagreement
+3. Notable Questions or Answers raised by commenters
+
+Comments: %s
+
+Reflect the range of opinions fairly and be concise.`
+
+// SummarizeDiscussion summarizes a page's comment section, focusing on
+// themes and opinions rather than facts.
+func (o *OllamaSummarizer) SummarizeDiscussion(ctx context.Context, comments string) (string, error) {
+	prompt, err := discussionRequest(comments, o.maxInputLen())
+	if err != nil {
+		return "", err
+	}
+	return o.generate(ctx, prompt)
+}
+
+func discussionRequest(comments string, maxLen int) (renderedPrompt, error) {
+	comments = strings.TrimSpace(comments)
+	if comments == "" {
+		return renderedPrompt{}, fmt.Errorf("empty text")
+	}
+	return splitPrompt(discussionPrompt, truncateMiddle(comments, maxLen)), nil
+}
+
+const critiquePrompt = `You are reviewing an automatically generated summary of a web page. Rate how well the summary captures the source text, and say whether the source itself was too short, garbled or off-topic (for example a cookie banner, error page or navigation menu) to summarize meaningfully.
+
+Source:
+%s
+
+Summary:
+%s
+
+Answer in exactly this format:
+SCORE: <1-5, where 5 means the summary is accurate and complete>
+UNSUMMARIZABLE: <yes or no>
+REASON: <one sentence>`
+
+// Critique is the model's assessment of one of its summaries.
+type Critique struct {
+	// Score rates from 1 to 5 how well the summary captures the source.
+	Score int
+	// Unsummarizable is set when the source was too short or garbled to
+	// summarize meaningfully, which usually points at bad extraction.
+	Unsummarizable bool
+	Reason         string
+}
+
+// Critique asks the model to rate summary against the text it was made
+// from.
+func (o *OllamaSummarizer) Critique(ctx context.Context, text, summary string) (Critique, error) {
+	prompt, err := critiqueRequest(text, summary, o.maxInputLen())
+	if err != nil {
+		return Critique{}, err
+	}
+	response, err := o.generate(ctx, prompt)
+	if err != nil {
+		return Critique{}, err
+	}
+	return parseCritique(response)
+}
+
+func critiqueRequest(text, summary string, maxLen int) (renderedPrompt, error) {
+	text = strings.TrimSpace(text)
+	summary = strings.TrimSpace(summary)
+	if text == "" || summary == "" {
+		return renderedPrompt{}, fmt.Errorf("empty text")
+	}
+	return splitPrompt(critiquePrompt, truncateMiddle(text, maxLen), summary), nil
+}
+
+// parseCritique reads the SCORE, UNSUMMARIZABLE and REASON lines of a
+// critique response, tolerating extra text and markup around them.
+func parseCritique(response string) (Critique, error) {
+	var critique Critique
+	for _, line := range strings.Split(response, "\n") {
+		name, value, ok := strings.Cut(strings.Trim(line, " \t*#-"), ":")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, " \t*")
+		switch strings.ToUpper(strings.TrimSpace(name)) {
+		case "SCORE":
+			fmt.Sscanf(value, "%d", &critique.Score)
+		case "UNSUMMARIZABLE":
+			critique.Unsummarizable = strings.HasPrefix(strings.ToLower(value), "yes")
+		case "REASON":
+			critique.Reason = value
+		}
+	}
+	if critique.Score < 1 || critique.Score > 5 {
+		return Critique{}, fmt.Errorf("no valid score in critique: %q", response)
+	}
+	return critique, nil
+}
@@ -0,0 +1,145 @@
+package summarizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// StructuredSummary is a summary broken into the sections of
+// FormatStructured, for output meant for programs rather than people.
+type StructuredSummary struct {
+	KeyPoints []string `json:"key_points"`
+	Terms     []Term   `json:"terms"`
+	Takeaways []string `json:"takeaways"`
+}
+
+// Term is an important term of a page and its brief explanation.
+type Term struct {
+	Term       string `json:"term"`
+	Definition string `json:"definition"`
+}
+
+// String lays the summary out like a FormatStructured summary.
+func (s StructuredSummary) String() string {
+	var b strings.Builder
+	b.WriteString("1. Key Points:\n")
+	for _, point := range s.KeyPoints {
+		b.WriteString("   - " + point + "\n")
+	}
+	b.WriteString("\n2. Important Terms:\n")
+	for _, term := range s.Terms {
+		b.WriteString("   - " + term.Term + ": " + term.Definition + "\n")
+	}
+	b.WriteString("\n3. Main Takeaways:\n")
+	for _, takeaway := range s.Takeaways {
+		b.WriteString("   - " + takeaway + "\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (s StructuredSummary) empty() bool {
+	return len(s.KeyPoints) == 0 && len(s.Terms) == 0 && len(s.Takeaways) == 0
+}
+
+// SummarizeStructured summarizes text as a StructuredSummary, asking the
+// model for JSON.
+func (o *OllamaSummarizer) SummarizeStructured(ctx context.Context, text string) (StructuredSummary, error) {
+	return o.SummarizeStructuredDocument(ctx, Document{Text: text})
+}
+
+// SummarizeStructuredDocument is like SummarizeStructured but also gives
+// the model the page's title and description.
+func (o *OllamaSummarizer) SummarizeStructuredDocument(ctx context.Context, doc Document) (StructuredSummary, error) {
+	rendered, err := summaryPrompt(o.logger, FormatJSON, doc, nil, o.maxInputLen())
+	if err != nil {
+		return StructuredSummary{}, err
+	}
+	response, err := o.generate(ctx, rendered)
+	if err != nil {
+		return StructuredSummary{}, err
+	}
+	return ParseStructuredSummary(response)
+}
+
+// ParseStructuredSummary reads a FormatJSON response. Models do not always
+// keep to JSON, so a response without a valid JSON object is read as a
+// FormatStructured summary instead, by its section headings.
+func ParseStructuredSummary(response string) (StructuredSummary, error) {
+	var summary StructuredSummary
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start >= 0 && end > start && json.Unmarshal([]byte(response[start:end+1]), &summary) == nil && !summary.empty() {
+		return summary, nil
+	}
+
+	summary = parseStructuredText(response)
+	if summary.empty() {
+		return StructuredSummary{}, fmt.Errorf("no structured summary in response: %q", truncateMiddle(response, 200))
+	}
+	return summary, nil
+}
+
+// parseStructuredText reads the bullets under the Key Points, Important
+// Terms and Main Takeaways headings of a FormatStructured summary.
+func parseStructuredText(text string) StructuredSummary {
+	var summary StructuredSummary
+	section := ""
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if heading := structuredHeading(line); heading != "" {
+			section = heading
+			continue
+		}
+		item := trimBullet(line)
+		if item == "" {
+			continue
+		}
+		switch section {
+		case "points":
+			summary.KeyPoints = append(summary.KeyPoints, item)
+		case "terms":
+			name, definition, ok := strings.Cut(item, ":")
+			if !ok {
+				name, definition, _ = strings.Cut(item, " - ")
+			}
+			summary.Terms = append(summary.Terms, Term{
+				Term:       strings.Trim(strings.TrimSpace(name), "*"),
+				Definition: strings.TrimSpace(definition),
+			})
+		case "takeaways":
+			summary.Takeaways = append(summary.Takeaways, item)
+		}
+	}
+	return summary
+}
+
+// trimBullet strips the bullet or number ("1.", "2)") a list item starts
+// with.
+func trimBullet(line string) string {
+	line = strings.TrimLeft(line, "-*• ")
+	if i := strings.IndexAny(line, ".)"); i > 0 && i <= 3 && strings.Trim(line[:i], "0123456789") == "" && strings.HasPrefix(line[i+1:], " ") {
+		line = line[i+1:]
+	}
+	return strings.TrimSpace(line)
+}
+
+// structuredHeading returns the section a heading line starts, or "" for
+// any other line.
+func structuredHeading(line string) string {
+	heading := strings.ToLower(strings.Trim(line, "#*0123456789.): "))
+	switch {
+	case len(heading) > 40:
+		return ""
+	case strings.HasPrefix(heading, "key point"):
+		return "points"
+	case strings.HasPrefix(heading, "important term"), strings.HasPrefix(heading, "terms"):
+		return "terms"
+	case strings.HasPrefix(heading, "main takeaway"), strings.HasPrefix(heading, "takeaway"):
+		return "takeaways"
+	}
+	return ""
+}
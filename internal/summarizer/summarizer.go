@@ -2,14 +2,21 @@ package summarizer
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"text/template"
 	"time"
+	"unicode/utf8"
 )
 
+// ContentUnderstanding is what was learned about a page: its cleaned-up
+// text, the notes (summary) made of it and when the server says it last
+// changed, zero when unknown.
 type ContentUnderstanding struct {
 	URL            string    `json:"url"`
 	SimplifiedText string    `json:"simplified_text"`
@@ -17,23 +24,128 @@ type ContentUnderstanding struct {
 	LastModified   time.Time `json:"last_modified"`
 }
 
+// Summarizer summarizes text. Summarize gives up, including between
+// retries, as soon as ctx is cancelled.
 type Summarizer interface {
-	Summarize(text string) (string, error)
+	Summarize(ctx context.Context, text string) (string, error)
+}
+
+// PageSummarizer is a Summarizer with everything the crawler asks of the
+// model. OllamaSummarizer and OpenAISummarizer both implement it.
+type PageSummarizer interface {
+	Summarizer
+	SummarizeDocument(ctx context.Context, doc Document, prompt *template.Template) (string, error)
+	SummarizeDiscussion(ctx context.Context, comments string) (string, error)
+	Compare(ctx context.Context, a, b Document) (string, error)
+	Critique(ctx context.Context, text, summary string) (Critique, error)
+	// HealthCheck checks that the server is reachable; Ready also waits
+	// for the model to load.
+	HealthCheck(ctx context.Context) error
+	Ready(ctx context.Context) error
+	Model() string
+	Format() Format
+	PromptTemplate() string
 }
 
 type OllamaSummarizer struct {
-	baseURL string
-	model   string
+	baseURL    string
+	model      string
+	format     Format
+	prompt     *template.Template
+	promptText string
+
+	// MaxInputLen is the most text, in bytes, summarized in a single call.
+	// Longer text is split into ChunkSize chunks that are summarized one by
+	// one, and the summaries of the chunks are then summarized together.
+	MaxInputLen int
+	ChunkSize   int
+
+	// Stream reads the response as Ollama generates it instead of waiting
+	// for all of it. A request then fails only when no new tokens arrive
+	// for IdleTimeout (default 30s), however long the whole response
+	// takes, rather than after a fixed two minutes.
+	Stream      bool
+	IdleTimeout time.Duration
+
+	// UseChatAPI sends requests to /api/chat, with the prompt's
+	// instructions as a system message and the content as a user message,
+	// instead of as a single prompt to /api/generate. Chat-tuned models
+	// tend to follow the requested layout more closely.
+	UseChatAPI bool
+
+	logger *slog.Logger
 }
 
-func NewOllamaSummarizer(baseURL, model string) *OllamaSummarizer {
+// Option configures an OllamaSummarizer.
+type Option func(*OllamaSummarizer) error
+
+// WithFormat selects the summary format; the default is FormatStructured.
+func WithFormat(format Format) Option {
+	return func(o *OllamaSummarizer) error {
+		o.format = format
+		return nil
+	}
+}
+
+// WithLogger logs to logger instead of slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *OllamaSummarizer) error {
+		if logger != nil {
+			o.logger = logger
+		}
+		return nil
+	}
+}
+
+// WithPromptTemplate summarizes with a custom text/template prompt, which
+// gets the page as {{.Text}}, {{.Title}} and {{.URL}}, instead of the
+// format's prompt. NewOllamaSummarizer fails if the template is invalid.
+func WithPromptTemplate(text string) Option {
+	return func(o *OllamaSummarizer) error {
+		prompt, err := ParsePrompt(text)
+		if err != nil {
+			return err
+		}
+		o.prompt = prompt
+		o.promptText = text
+		return nil
+	}
+}
+
+func NewOllamaSummarizer(baseURL, model string, opts ...Option) (*OllamaSummarizer, error) {
 	if model == "" {
 		model = "mistral" // default model
 	}
-	return &OllamaSummarizer{
-		baseURL: baseURL,
-		model:   model,
+	o := &OllamaSummarizer{
+		baseURL:     baseURL,
+		model:       model,
+		format:      FormatStructured,
+		MaxInputLen: maxInputLen,
+		ChunkSize:   defaultChunkSize,
+		logger:      slog.Default(),
 	}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// Model returns the name of the Ollama model used.
+func (o *OllamaSummarizer) Model() string {
+	return o.model
+}
+
+// Format returns the summary format the summarizer produces.
+func (o *OllamaSummarizer) Format() Format {
+	return o.format
+}
+
+// PromptTemplate returns the custom prompt template set with
+// WithPromptTemplate, or "" when the format's prompt is used.
+func (o *OllamaSummarizer) PromptTemplate() string {
+	return o.promptText
 }
 
 type ollamaRequest struct {
@@ -42,17 +154,54 @@ type ollamaRequest struct {
 	Stream bool   `json:"stream"`
 }
 
+// ollamaResponse is a response, or a streamed chunk of one, from either
+// /api/generate, which fills in Response, or /api/chat, which fills in
+// Message.
 type ollamaResponse struct {
-	Response string `json:"response"`
-	Error    string `json:"error,omitempty"`
+	Response string      `json:"response"`
+	Message  chatMessage `json:"message"`
+	Done     bool        `json:"done"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// content returns the generated text.
+func (r *ollamaResponse) content() string {
+	if r.Message.Content != "" {
+		return r.Message.Content
+	}
+	return r.Response
 }
 
-func (o *OllamaSummarizer) makeRequest(jsonData []byte) (*ollamaResponse, error) {
+// defaultIdleTimeout is how long a streamed response may go without new
+// tokens when IdleTimeout is not set.
+const defaultIdleTimeout = 30 * time.Second
+
+// requestClient returns the client for one generation request. Requests
+// time out after two minutes unless ctx has its own deadline.
+func requestClient(ctx context.Context) *http.Client {
 	client := &http.Client{
 		Timeout: 120 * time.Second,
 	}
+	if _, ok := ctx.Deadline(); ok {
+		// The caller's deadline wins, e.g. a long StartupTimeout for a
+		// slow model load.
+		client.Timeout = 0
+	}
+	return client
+}
+
+// makeRequest posts jsonData to the API endpoint at path and decodes the
+// response.
+func (o *OllamaSummarizer) makeRequest(ctx context.Context, path string, jsonData []byte) (*ollamaResponse, error) {
+	client := requestClient(ctx)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Post(fmt.Sprintf("%s/api/generate", o.baseURL), "application/json", bytes.NewBuffer(jsonData))
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %v", err)
 	}
@@ -70,41 +219,238 @@ func (o *OllamaSummarizer) makeRequest(jsonData []byte) (*ollamaResponse, error)
 	return &result, nil
 }
 
-// Summarize generates a summary of the given text using Ollama
-func (o *OllamaSummarizer) Summarize(text string) (string, error) {
+// streamRequest sends a streaming request to the API endpoint at path and
+// returns the concatenated response, giving up when no chunk arrives for
+// IdleTimeout.
+func (o *OllamaSummarizer) streamRequest(ctx context.Context, path string, jsonData []byte) (string, error) {
+	idle := o.IdleTimeout
+	if idle <= 0 {
+		idle = defaultIdleTimeout
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	timer := time.AfterFunc(idle, cancel)
+	defer timer.Stop()
+	stalled := func() bool { return ctx.Err() != nil && parent.Err() == nil }
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if stalled() {
+			return "", fmt.Errorf("ollama did not respond within %v", idle)
+		}
+		return "", fmt.Errorf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var response strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk ollamaResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if stalled() {
+				return "", fmt.Errorf("ollama stopped responding for %v after %d bytes", idle, response.Len())
+			}
+			if err == io.EOF {
+				return "", fmt.Errorf("ollama response ended early after %d bytes", response.Len())
+			}
+			return "", fmt.Errorf("failed to decode response: %v", err)
+		}
+		if !timer.Stop() {
+			// The timer fired while this chunk was being decoded.
+			return "", fmt.Errorf("ollama stopped responding for %v after %d bytes", idle, response.Len())
+		}
+		timer.Reset(idle)
+
+		if chunk.Error != "" {
+			return "", fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		response.WriteString(chunk.content())
+		if chunk.Done {
+			return response.String(), nil
+		}
+	}
+}
+
+// HealthCheck reports whether the Ollama server is reachable and has the
+// configured model.
+func (o *OllamaSummarizer) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/tags", o.baseURL), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ollama is not reachable at %s: %v", o.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ollama health check returned status %d", resp.StatusCode)
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return fmt.Errorf("failed to decode model list: %v", err)
+	}
+	for _, model := range tags.Models {
+		if model.Name == o.model || strings.TrimSuffix(model.Name, ":latest") == o.model {
+			return nil
+		}
+	}
+	return fmt.Errorf("model %q is not available in ollama", o.model)
+}
+
+// WarmUp loads the model into memory, so that the first summary does not
+// also wait for it to load. Ollama loads a model when asked to generate
+// from an empty prompt.
+func (o *OllamaSummarizer) WarmUp(ctx context.Context) error {
+	jsonData, err := json.Marshal(ollamaRequest{Model: o.model})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %v", err)
+	}
+	if _, err := o.makeRequest(ctx, generatePath, jsonData); err != nil {
+		return fmt.Errorf("failed to load model %q: %v", o.model, err)
+	}
+	return nil
+}
+
+// Ready checks that Ollama is reachable and has the model, then loads it,
+// all within ctx. Its errors tell an unreachable server apart from a model
+// that is still loading when ctx expires.
+func (o *OllamaSummarizer) Ready(ctx context.Context) error {
+	start := time.Now()
+	if err := o.HealthCheck(ctx); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("ollama at %s did not respond within %v: %v", o.baseURL, time.Since(start).Round(time.Second), err)
+		}
+		return err
+	}
+
+	o.logger.Debug("Ollama is up, loading model", "model", o.model)
+	if err := o.WarmUp(ctx); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("ollama is up but model %q was still loading after %v", o.model, time.Since(start).Round(time.Second))
+		}
+		return err
+	}
+	o.logger.Debug("Model ready", "model", o.model, "elapsed", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// Summarize generates a summary of the given text using Ollama, giving up,
+// including between retries, as soon as ctx is cancelled.
+func (o *OllamaSummarizer) Summarize(ctx context.Context, text string) (string, error) {
+	return o.SummarizeWithPrompt(ctx, text, nil)
+}
+
+// SummarizeWithPrompt summarizes text using a custom prompt template from
+// ParsePrompt instead of the summarizer's own prompt. A nil prompt uses the
+// template set with WithPromptTemplate, or else the format's prompt.
+func (o *OllamaSummarizer) SummarizeWithPrompt(ctx context.Context, text string, prompt *template.Template) (string, error) {
+	return o.SummarizeDocument(ctx, Document{Text: text}, prompt)
+}
+
+// SummarizeDocument is like SummarizeWithPrompt but also gives the model
+// the page's title and description, which help it tell what the page is
+// about.
+func (o *OllamaSummarizer) SummarizeDocument(ctx context.Context, doc Document, prompt *template.Template) (string, error) {
+	if prompt == nil {
+		prompt = o.prompt
+	}
+	doc.Text = strings.TrimSpace(doc.Text)
+	if len(doc.Text) > o.maxInputLen() {
+		return o.summarizeChunks(ctx, doc, prompt)
+	}
+
+	rendered, err := summaryPrompt(o.logger, o.format, doc, prompt, o.maxInputLen())
+	if err != nil {
+		return "", err
+	}
+	return o.generate(ctx, rendered)
+}
+
+// summaryPrompt cleans up and, if longer than maxLen, shortens doc's text
+// and renders the prompt for it.
+func summaryPrompt(logger *slog.Logger, format Format, doc Document, prompt *template.Template, maxLen int) (renderedPrompt, error) {
 	// Trim and clean the text
-	text = strings.TrimSpace(text)
-	if text == "" {
-		return "", fmt.Errorf("empty text")
+	doc.Text = strings.TrimSpace(doc.Text)
+	if doc.Text == "" {
+		return renderedPrompt{}, fmt.Errorf("empty text")
 	}
 
-	// Log input text length
-	log.Printf("Input text length: %d characters\n", len(text))
+	logger.Debug("Summarizing text", "length", len(doc.Text))
 
 	// If text is too long, take first and last parts
-	const maxLen = 12000
-	if len(text) > maxLen {
-		firstPart := text[:maxLen/2]
-		lastPart := text[len(text)-maxLen/2:]
-		text = firstPart + "\n...\n" + lastPart
-	}
+	doc.Text = truncateMiddle(doc.Text, maxLen)
 
-	// Prepare the prompt for structured summary
-	prompt := fmt.Sprintf(`You are a helpful AI assistant. Create a structured summary of this text with:
+	return buildPrompt(format, doc, prompt)
+}
 
-1. Key Points (3-4 bullet points)
-2. Important Terms (3-4 terms with brief explanations)
-3. Main Takeaways (2-3 points)
+// maxInputLen is the default for the most text sent to the model in one
+// prompt.
+const maxInputLen = 12000
 
-Text: %s
+func (o *OllamaSummarizer) maxInputLen() int {
+	if o.MaxInputLen <= 0 {
+		return maxInputLen
+	}
+	return o.MaxInputLen
+}
+
+// truncateMiddle shortens text longer than maxLen bytes to its first and
+// last parts. The cuts are moved back to rune boundaries so that no
+// multibyte character is split.
+func truncateMiddle(text string, maxLen int) string {
+	if len(text) <= maxLen {
+		return text
+	}
+	head := maxLen / 2
+	for head > 0 && !utf8.RuneStart(text[head]) {
+		head--
+	}
+	tail := len(text) - maxLen/2
+	for tail < len(text) && !utf8.RuneStart(text[tail]) {
+		tail++
+	}
+	return text[:head] + "\n...\n" + text[tail:]
+}
 
-Remember to be concise and specific.`, text)
+// The Ollama API endpoints generate sends prompts to.
+const (
+	generatePath = "/api/generate"
+	chatPath     = "/api/chat"
+)
 
+// generate sends prompt to Ollama, retrying failed requests, and returns
+// the response.
+func (o *OllamaSummarizer) generate(ctx context.Context, prompt renderedPrompt) (string, error) {
 	// Make request to Ollama
-	reqBody := ollamaRequest{
+	path := generatePath
+	var reqBody any = ollamaRequest{
 		Model:  o.model,
-		Prompt: prompt,
-		Stream: false,
+		Prompt: prompt.text,
+		Stream: o.Stream,
+	}
+	if o.UseChatAPI {
+		var messages []chatMessage
+		if prompt.system != "" {
+			messages = append(messages, chatMessage{Role: "system", Content: prompt.system})
+		}
+		messages = append(messages, chatMessage{Role: "user", Content: prompt.user})
+		path = chatPath
+		reqBody = chatRequest{Model: o.model, Messages: messages, Stream: o.Stream}
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -112,23 +458,48 @@ Remember to be concise and specific.`, text)
 		return "", fmt.Errorf("failed to marshal request: %v", err)
 	}
 
+	return withRetries(ctx, o.logger, func() (string, error) {
+		if o.Stream {
+			return o.streamRequest(ctx, path, jsonData)
+		}
+		resp, err := o.makeRequest(ctx, path, jsonData)
+		if err != nil {
+			return "", err
+		}
+		return resp.content(), nil
+	})
+}
+
+// withRetries calls request up to three times, backing off between
+// failed attempts, and returns the first summary it produces.
+func withRetries(ctx context.Context, logger *slog.Logger, request func() (string, error)) (string, error) {
 	// Make the request with retries
 	var summary string
 	maxAttempts := 3
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		log.Printf("Attempt %d of %d to generate summary\n", attempt, maxAttempts)
+		logger.Debug("Generating summary", "attempt", attempt, "max_attempts", maxAttempts)
 
-		resp, err := o.makeRequest(jsonData)
+		response, err := request()
 		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
 			if attempt == maxAttempts {
 				return "", fmt.Errorf("failed to generate summary after %d attempts: %v", maxAttempts, err)
 			}
-			log.Printf("Attempt %d failed: %v. Retrying...\n", attempt, err)
-			time.Sleep(time.Duration(attempt) * time.Second)
+			logger.Warn("Summary attempt failed, retrying", "attempt", attempt, "error", err)
+
+			timer := time.NewTimer(time.Duration(attempt) * time.Second)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return "", ctx.Err()
+			case <-timer.C:
+			}
 			continue
 		}
 
-		summary = resp.Response
+		summary = response
 		break
 	}
 
@@ -0,0 +1,56 @@
+package summarizer
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateMiddle(t *testing.T) {
+	texts := map[string]string{
+		"cjk":   strings.Repeat("日本語の文章です。", 20),
+		"emoji": strings.Repeat("🙂👍🏽🎉", 20),
+		"mixed": strings.Repeat("a日🙂b", 30),
+	}
+	for name, text := range texts {
+		for maxLen := 1; maxLen <= len(text); maxLen++ {
+			got := truncateMiddle(text, maxLen)
+			if !utf8.ValidString(got) || strings.ContainsRune(got, utf8.RuneError) {
+				t.Fatalf("%s: truncateMiddle(_, %d) split a rune: %q", name, maxLen, got)
+			}
+			if maxLen == len(text) {
+				if got != text {
+					t.Errorf("%s: text of exactly maxLen bytes was changed", name)
+				}
+				continue
+			}
+			head, tail, ok := strings.Cut(got, "\n...\n")
+			if !ok {
+				t.Fatalf("%s: truncateMiddle(_, %d) = %q, want a cut", name, maxLen, got)
+			}
+			if len(head)+len(tail) > maxLen || !strings.HasPrefix(text, head) || !strings.HasSuffix(text, tail) {
+				t.Errorf("%s: truncateMiddle(_, %d) kept %q and %q", name, maxLen, head, tail)
+			}
+		}
+	}
+}
+
+func TestSummaryPromptTruncatesOnRunes(t *testing.T) {
+	// Three-byte characters, with maxLen/2 landing inside one.
+	text := strings.Repeat("漢字", 100)
+	prompt, err := summaryPrompt(slog.Default(), FormatParagraph, Document{Text: text}, nil, 101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range []string{prompt.text, prompt.user} {
+		if !utf8.ValidString(part) || strings.ContainsRune(part, utf8.RuneError) {
+			t.Errorf("prompt has a split rune: %q", part)
+		}
+	}
+	// Both cuts back off to whole characters: 48 bytes at each end.
+	want := strings.Repeat("漢字", 8) + "\n...\n" + strings.Repeat("漢字", 8)
+	if !strings.Contains(prompt.user, want) {
+		t.Errorf("prompt does not hold the truncated text: %q", prompt.user)
+	}
+}